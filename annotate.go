@@ -0,0 +1,249 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+type annotator struct {
+	*writeOptions
+	settings map[string]Setting
+	buf      bytes.Buffer
+	fresh    bool // No blank line needed before next entry.
+}
+
+// annotate the configuration as commented YAML.
+func annotate(config interface{}, o *writeOptions) (data []byte, err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			err = asError(x)
+		}
+	}()
+
+	a := &annotator{
+		writeOptions: o,
+		settings:     make(map[string]Setting),
+		fresh:        true,
+	}
+
+	for _, s := range Settings(config) {
+		a.settings[s.Path] = s
+	}
+
+	a.struc(reflect.ValueOf(config).Elem(), "", "")
+
+	data = a.buf.Bytes()
+	return
+}
+
+func (a *annotator) struc(struc reflect.Value, prefix, indent string) {
+	for i := 0; i < struc.Type().NumField(); i++ {
+		value := struc.Field(i)
+		if !value.CanInterface() {
+			continue
+		}
+
+		field := struc.Type().Field(i)
+		key := strings.ToLower(field.Name)
+
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64, reflect.String:
+			a.leaf(path, key, field, value, indent)
+
+		case reflect.Slice:
+			switch value.Type().Elem().Kind() {
+			case reflect.String:
+				a.leaf(path, key, field, value, indent)
+			}
+
+		case reflect.Ptr:
+			if value.IsNil() || value.Type().Elem().Kind() != reflect.Struct {
+				break
+			}
+			value = value.Elem()
+			fallthrough
+
+		case reflect.Struct:
+			if field.Anonymous {
+				a.struc(value, prefix, indent)
+			} else if a.hasSettings(path) {
+				a.entry()
+				a.doc(field, indent)
+				if a.changed != nil && !a.hasChanges(value, path) {
+					a.comment(indent, key+":") // Null would reset the whole section.
+				} else {
+					a.value(indent, key+":")
+				}
+				a.fresh = true
+				a.struc(value, path, indent+"  ")
+			}
+		}
+	}
+}
+
+func (a *annotator) hasSettings(prefix string) bool {
+	for path := range a.settings {
+		if strings.HasPrefix(path, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasChanges reports if a struct has settings which are written uncommented
+// in minimal mode.
+func (a *annotator) hasChanges(struc reflect.Value, prefix string) bool {
+	for i := 0; i < struc.Type().NumField(); i++ {
+		value := struc.Field(i)
+		if !value.CanInterface() {
+			continue
+		}
+
+		field := struc.Type().Field(i)
+		key := strings.ToLower(field.Name)
+
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Ptr:
+			if value.IsNil() || value.Type().Elem().Kind() != reflect.Struct {
+				break
+			}
+			value = value.Elem()
+			fallthrough
+
+		case reflect.Struct:
+			if field.Anonymous {
+				path = prefix
+			}
+			if a.hasChanges(value, path) {
+				return true
+			}
+
+		default:
+			if _, ok := a.settings[path]; !ok {
+				break
+			}
+			if isSecret(value) && !a.secrets {
+				break
+			}
+			if a.changed(path, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (a *annotator) leaf(path, key string, field reflect.StructField, value reflect.Value, indent string) {
+	s := a.settings[path]
+
+	info := "type: " + s.Type.String()
+	if values := field.Tag.Get("values"); values != "" {
+		info += ", values: " + values
+	} else if value.Kind() == reflect.Bool {
+		info += ", values: true, false"
+	}
+	if s.Default != "" {
+		info += ", default: " + s.Default
+	}
+
+	a.entry()
+	a.doc(field, indent)
+	a.comment(indent, info)
+
+	switch {
+	case isSecret(value) && !a.secrets:
+		a.comment(indent, key+": "+redacted)
+
+	case a.changed != nil && !a.changed(path, value):
+		a.comment(indent, key+": "+render(value))
+
+	default:
+		a.value(indent, key+": "+render(value))
+	}
+}
+
+func (a *annotator) entry() {
+	if !a.fresh {
+		a.buf.WriteString("\n")
+	}
+	a.fresh = false
+}
+
+func (a *annotator) doc(field reflect.StructField, indent string) {
+	if doc := field.Tag.Get("doc"); doc != "" {
+		for _, line := range strings.Split(doc, "\n") {
+			a.comment(indent, line)
+		}
+	}
+}
+
+func (a *annotator) comment(indent, text string) {
+	fmt.Fprintf(&a.buf, "%s# %s\n", indent, text)
+}
+
+func (a *annotator) value(indent, text string) {
+	if a.commentOut {
+		a.buf.WriteString("# ")
+	}
+	fmt.Fprintf(&a.buf, "%s%s\n", indent, text)
+}
+
+// render a settable value as a single-line YAML value.
+func render(value reflect.Value) string {
+	switch value.Kind() {
+	case reflect.Slice:
+		items := make([]string, value.Len())
+		for i := range items {
+			items[i] = renderJSON(value.Index(i).String())
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+
+	case reflect.String:
+		return renderString(value.String())
+
+	default:
+		data, err := yaml.Marshal(value.Interface())
+		if err != nil {
+			panic(err)
+		}
+		return strings.TrimSuffix(string(data), "\n")
+	}
+}
+
+func renderString(s string) string {
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		panic(err)
+	}
+	if repr := strings.TrimSuffix(string(data), "\n"); !strings.Contains(repr, "\n") {
+		return repr
+	}
+	return renderJSON(s)
+}
+
+func renderJSON(s string) string {
+	data, err := json.Marshal(s)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
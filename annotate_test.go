@@ -0,0 +1,130 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+type annotateConfig struct {
+	Comment string `doc:"Free-form text."`
+
+	Size struct {
+		Width  uint32 `doc:"Horizontal resolution."`
+		Height uint32
+	} `doc:"Window geometry."`
+
+	Audio struct {
+		Enabled bool
+		Format  string `values:"s16le, f32le" doc:"Sample format.\nLittle-endian only."`
+		Devices []string
+		Latency time.Duration
+	}
+}
+
+const testAnnotatedYAML = `# Free-form text.
+# type: string
+comment: "true"
+
+# Window geometry.
+size:
+  # Horizontal resolution.
+  # type: uint32, default: 640
+  width: 800
+
+  # type: uint32, default: 480
+  height: 480
+
+audio:
+  # type: bool, values: true, false
+  enabled: false
+
+  # Sample format.
+  # Little-endian only.
+  # type: string, values: s16le, f32le, default: s16le
+  format: s16le
+
+  # type: []string
+  devices: ["default", "hw:0,0"]
+
+  # type: time.Duration, default: 10ms
+  latency: 10ms
+`
+
+func TestAnnotated(t *testing.T) {
+	c := new(annotateConfig)
+	c.Size.Width = 640
+	c.Size.Height = 480
+	c.Audio.Format = "s16le"
+	c.Audio.Latency = 10 * time.Millisecond
+
+	Defaults(c)
+
+	c.Comment = "true"
+	c.Size.Width = 800
+	c.Audio.Devices = []string{"default", "hw:0,0"}
+
+	b := new(bytes.Buffer)
+	if err := Write(b, c, Annotated()); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); s != testAnnotatedYAML {
+		t.Error(s)
+	}
+
+	c2 := new(annotateConfig)
+	if err := Read(b, c2); err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(c, c2) {
+		t.Error(Diff(c, c2))
+	}
+
+	b.Reset()
+	if err := Write(b, c, CommentedOut()); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(b.String()), "\n") {
+		if line != "" && !strings.HasPrefix(line, "#") && !strings.HasPrefix(strings.TrimSpace(line), "#") {
+			t.Error(line)
+		}
+	}
+	if s := b.String(); !strings.Contains(s, "\n# size:\n") || !strings.Contains(s, "\n#   width: 800\n") {
+		t.Error(s)
+	}
+}
+
+func TestAnnotatedMinimal(t *testing.T) {
+	newConfig := func() *annotateConfig {
+		c := new(annotateConfig)
+		c.Size.Width = 640
+		c.Size.Height = 480
+		c.Audio.Format = "s16le"
+		Defaults(c)
+		return c
+	}
+
+	c := newConfig()
+	c.Audio.Enabled = true
+
+	b := new(bytes.Buffer)
+	if err := Write(b, c, Annotated(), Minimal()); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); !strings.Contains(s, "\n# size:\n") || !strings.Contains(s, "\naudio:\n") {
+		t.Error(s)
+	}
+
+	c2 := newConfig()
+	if err := Read(b, c2); err != nil {
+		t.Fatal(err)
+	}
+	if !Equal(c, c2) {
+		t.Error(Diff(c, c2))
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Change of a configuration value.
+type Change struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %v -> %v", c.Path, c.Old, c.New)
+}
+
+// Diff lists the differences between two configuration objects.  Old values
+// are from a and new values are from b.  A nil Old or New value means that the
+// path is reachable only in one of the objects (e.g. due to a nil pointer).
+func Diff(a, b interface{}) []Change {
+	return takeSnapshot(a).diff(takeSnapshot(b))
+}
+
+// Equal checks if the settable values of two configuration objects are equal.
+func Equal(a, b interface{}) bool {
+	return len(Diff(a, b)) == 0
+}
+
+// snapshot holds copies of the settable values of a configuration object.
+type snapshot struct {
+	paths  []string
+	values map[string]interface{}
+}
+
+func takeSnapshot(config interface{}) (s snapshot) {
+	s.values = make(map[string]interface{})
+
+	walk("", reflect.ValueOf(config), func(path string, _ reflect.StructField, value reflect.Value) {
+		s.paths = append(s.paths, path)
+		s.values[path] = copyValue(value).Interface()
+	})
+
+	return
+}
+
+// diff lists the changes from a to b.  Paths which exist only in a are listed
+// before the paths of b.
+func (a snapshot) diff(b snapshot) (changes []Change) {
+	for _, path := range a.paths {
+		if _, found := b.values[path]; !found {
+			changes = append(changes, Change{path, a.values[path], nil})
+		}
+	}
+
+	for _, path := range b.paths {
+		old := a.values[path]
+		new := b.values[path]
+		if !equalValues(old, new) {
+			changes = append(changes, Change{path, old, new})
+		}
+	}
+
+	return
+}
+
+// copyValue duplicates slices; other values are returned as is.
+func copyValue(value reflect.Value) reflect.Value {
+	if value.Kind() == reflect.Slice && !value.IsNil() {
+		dup := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		reflect.Copy(dup, value)
+		return dup
+	}
+
+	return value
+}
+
+// equalValues compares settable values.  Nil and empty slices are equal.
+func equalValues(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	x := reflect.ValueOf(a)
+	y := reflect.ValueOf(b)
+
+	if x.Type() != y.Type() {
+		return false
+	}
+
+	if x.Kind() == reflect.Slice {
+		if x.Len() != y.Len() {
+			return false
+		}
+		for i := 0; i < x.Len(); i++ {
+			if x.Index(i).Interface() != y.Index(i).Interface() {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
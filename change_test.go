@@ -0,0 +1,41 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	a := new(testConfig)
+	a.Foo.Key11 = []string{}
+
+	b := new(testConfig)
+	b.Bar = 10
+	b.Foo.Key11 = nil
+	b.Baz.Embed2.TestConfigEmbed = &TestConfigEmbed{true}
+
+	if !Equal(a, Clone(a)) {
+		t.Fail()
+	}
+	if Equal(a, b) {
+		t.Fail()
+	}
+
+	if changes := Diff(a, b); !reflect.DeepEqual(changes, []Change{
+		{"bar", 0, 10},
+		{"baz.embed2.embedded", nil, true},
+	}) {
+		t.Errorf("%v", changes)
+	}
+
+	if changes := Diff(b, a); !reflect.DeepEqual(changes, []Change{
+		{"baz.embed2.embedded", true, nil},
+		{"bar", 10, 0},
+	}) {
+		t.Errorf("%v", changes)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+)
+
+// Clone makes a deep copy of the configuration object.  The result is a pointer
+// of the same type as config.  Nested structs which are referenced through
+// pointers and string lists are copied too.
+func Clone(config interface{}) interface{} {
+	return duplicate(config).Interface()
+}
+
+// duplicate allocates a deep copy of the struct which config points to.
+func duplicate(config interface{}) reflect.Value {
+	orig := reflect.ValueOf(config).Elem()
+	dup := reflect.New(orig.Type())
+	dup.Elem().Set(orig)
+	deepen(dup.Elem())
+	return dup
+}
+
+// deepen replaces the nested structs and slices of a shallow struct copy with
+// copies of their own.
+func deepen(struc reflect.Value) {
+	for i := 0; i < struc.NumField(); i++ {
+		value := struc.Field(i)
+		if !value.CanSet() {
+			continue
+		}
+
+		switch value.Kind() {
+		case reflect.Slice:
+			value.Set(copyValue(value))
+
+		case reflect.Ptr:
+			if value.IsNil() || value.Type().Elem().Kind() != reflect.Struct {
+				break
+			}
+			p := reflect.New(value.Type().Elem())
+			p.Elem().Set(value.Elem())
+			deepen(p.Elem())
+			value.Set(p)
+
+		case reflect.Struct:
+			deepen(value)
+		}
+	}
+}
+
+// commit copies the exported fields of src to dst.  Nested structs which are
+// referenced through pointers in both are updated in place.
+func commit(dst, src reflect.Value) {
+	for i := 0; i < dst.NumField(); i++ {
+		value := dst.Field(i)
+		if !value.CanSet() {
+			continue
+		}
+
+		switch value.Kind() {
+		case reflect.Ptr:
+			if !value.IsNil() && !src.Field(i).IsNil() && value.Type().Elem().Kind() == reflect.Struct {
+				commit(value.Elem(), src.Field(i).Elem())
+			} else {
+				value.Set(src.Field(i))
+			}
+
+		case reflect.Struct:
+			commit(value, src.Field(i))
+
+		default:
+			value.Set(src.Field(i))
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	c := new(testConfig)
+	c.Baz.Embed2.TestConfigEmbed = new(TestConfigEmbed)
+
+	if err := Read(strings.NewReader(testConfigYAML), c); err != nil {
+		t.Fatal(err)
+	}
+
+	dup := Clone(c).(*testConfig)
+
+	testConfigValues(t, dup)
+
+	if dup.Baz.Embed2.TestConfigEmbed == c.Baz.Embed2.TestConfigEmbed {
+		t.Error("pointer was not copied")
+	}
+
+	dup.Foo.Key11[0] = "goodbye"
+	dup.Baz.Embed2.Embedded = true
+
+	testConfigValues(t, c)
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"sync"
+)
+
+var (
+	defaultLock sync.Mutex
+	defaults    = make(map[interface{}]reflect.Value)
+)
+
+// Defaults captures the current values of the configuration object as its
+// defaults.  It should be called after the object has been initialized with
+// default values, before reading files or parsing flags.  Reset restores the
+// captured values, and Settings reports them as defaults.  A copy of the
+// values is retained for as long as the program runs.
+func Defaults(config interface{}) {
+	base := duplicate(config)
+
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+
+	defaults[config] = base
+}
+
+// capturedDefaults returns a pointer to a copy of the defaults, or an invalid
+// value if they haven't been captured.
+func capturedDefaults(config interface{}) reflect.Value {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+
+	return defaults[config]
+}
+
+// shareDefaults makes the captured defaults of the configuration object
+// available to a temporary copy of it until done is called.
+func shareDefaults(config, tmp interface{}) (done func()) {
+	defaultLock.Lock()
+	defer defaultLock.Unlock()
+
+	base, found := defaults[config]
+	if !found {
+		return func() {}
+	}
+
+	defaults[tmp] = base
+
+	return func() {
+		defaultLock.Lock()
+		defer defaultLock.Unlock()
+
+		delete(defaults, tmp)
+	}
+}
+
+// Reset a field or a subtree of the configuration object to its default
+// value.  The whole object is reset if path is empty.  Fields are reset to
+// zero values if defaults haven't been captured.
+func Reset(config interface{}, path string) (err error) {
+	defer func() {
+		err = asError(recover())
+	}()
+
+	MustReset(config, path)
+	return
+}
+
+// MustReset a field or a subtree of the configuration object to its default
+// value.  Panic if the field doesn't exist.
+//
+// See Reset for details.
+func MustReset(config interface{}, path string) {
+	defer watch(config)()
+
+	node := reflect.ValueOf(config).Elem()
+	if path != "" {
+		node = lookup(config, path)
+	}
+
+	base := capturedDefaults(config)
+	if !base.IsValid() {
+		zeroValues(node)
+		return
+	}
+
+	orig := base.Elem()
+	if path != "" {
+		orig = lookup(base.Interface(), path)
+	}
+
+	if node.Kind() == reflect.Ptr && !node.IsNil() && !orig.IsNil() {
+		node = node.Elem()
+		orig = orig.Elem()
+	}
+
+	switch {
+	case node.Kind() == reflect.Struct:
+		commit(node, duplicateValue(orig))
+
+	case node.Kind() == reflect.Ptr && !orig.IsNil():
+		node.Set(duplicateValue(orig.Elem()).Addr())
+
+	default:
+		node.Set(copyValue(orig))
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReset(t *testing.T) {
+	c := new(testConfig)
+	c.Bar = 100
+	c.Foo.Key10 = "default"
+	c.Foo.Key11 = []string{"default"}
+	c.Baz.Embed2.TestConfigEmbed = new(TestConfigEmbed)
+	embed2 := c.Baz.Embed2.TestConfigEmbed
+
+	Defaults(c)
+
+	if err := Read(strings.NewReader(testConfigYAML), c); err != nil {
+		t.Fatal(err)
+	}
+	if err := Assign(c, "baz.embed2.embedded=true"); err != nil {
+		t.Fatal(err)
+	}
+
+	found := false
+	for _, s := range Settings(c) {
+		if s.Path == "bar" {
+			found = true
+			if s.Default != "100" {
+				t.Errorf("%#v", s)
+			}
+		}
+	}
+	if !found {
+		t.Error("bar setting not found")
+	}
+
+	if err := Reset(c, "bar"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bar != 100 || c.Foo.Key10 != "hello, world" {
+		t.Fail()
+	}
+
+	if err := Assign(c, "!foo"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Foo.Key1 || c.Foo.Key10 != "default" || !reflect.DeepEqual(c.Foo.Key11, []string{"default"}) {
+		t.Errorf("%#v", c.Foo)
+	}
+
+	c.Foo.Key11[0] = "changed"
+
+	if err := Reset(c, ""); err != nil {
+		t.Fatal(err)
+	}
+	if c.Baz.Embedded || c.Baz.Embed2.Embedded || c.Baz.Interval != 0 || c.Foo.Key11[0] != "default" {
+		t.Errorf("%#v", c)
+	}
+	if c.Baz.Embed2.TestConfigEmbed != embed2 {
+		t.Error("nested struct was replaced")
+	}
+
+	if err := Reset(c, "nonexistent"); err == nil {
+		t.Fail()
+	}
+}
+
+func TestResetInTransaction(t *testing.T) {
+	c := new(validatedConfig)
+	c.Bar = 100
+
+	Defaults(c)
+
+	c.Bar = 1
+
+	if err := AssignAll(c, "!bar"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bar != 100 {
+		t.Error(c.Bar)
+	}
+}
+
+func TestResetNilPointer(t *testing.T) {
+	c := new(testConfig)
+	c.Baz.Embed2.TestConfigEmbed = &TestConfigEmbed{Embedded: true}
+
+	Defaults(c)
+
+	c.Baz.Embed2.TestConfigEmbed = nil
+
+	if err := Reset(c, "baz.embed2.testconfigembed"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Baz.Embed2.TestConfigEmbed == nil || !c.Baz.Embed2.Embedded {
+		t.Fatalf("%#v", c.Baz.Embed2)
+	}
+
+	c.Baz.Embed2.Embedded = false
+
+	if err := Reset(c, ""); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Baz.Embed2.Embedded {
+		t.Error("defaults were modified")
+	}
+}
+
+func TestResetWithoutDefaults(t *testing.T) {
+	c := new(testConfig)
+	c.Bar = 100
+	c.Foo.Key2 = 200
+
+	if err := Reset(c, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bar != 100 || c.Foo.Key2 != 0 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,462 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EditFile sets a value in a YAML file without rewriting the rest of the
+// configuration.  Only the lines of the changed value are replaced: comments,
+// blank lines, indentation and the order of keys are preserved elsewhere.
+// Missing or empty intermediate mappings are created, and the file is created
+// if it doesn't exist.  The key is looked up from the first document of a
+// multi-document file; the other documents are left intact.  The file is
+// replaced in the same way as by WriteFile.
+func EditFile(filename, path string, value interface{}) error {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return err
+	}
+
+	return editFile(filename, func(e *editor) error {
+		return e.edit(path, &node)
+	})
+}
+
+// UnsetInFile removes a key from a YAML file without rewriting the rest of the
+// configuration.  The key's lines and its head comment are removed; the rest
+// of the file is preserved as with EditFile.  It's not an error if the key
+// doesn't exist.
+func UnsetInFile(filename, path string) error {
+	return editFile(filename, func(e *editor) error {
+		return e.edit(path, nil)
+	})
+}
+
+func editFile(filename string, edit func(*editor) error) (err error) {
+	unlock, err := lockFile(filename)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return
+	}
+
+	e, err := newEditor(data)
+	if err != nil {
+		return
+	}
+
+	if err = edit(e); err != nil {
+		return
+	}
+
+	return replaceFile(filename, []byte(strings.Join(e.lines, "")), 0, false)
+}
+
+// editor splices changes into the lines of the first document of a YAML
+// stream.  The node positions are not updated, so only one change can be made.
+type editor struct {
+	lines  []string   // Including line terminators.
+	root   *yaml.Node // Top-level node of the first document, or nil.
+	end    int        // Index of the line after the first document.
+	indent int        // Indentation step for new nested mappings.
+	eol    string     // Line terminator for new lines.
+}
+
+func newEditor(data []byte) (e *editor, err error) {
+	e = &editor{
+		lines:  strings.SplitAfter(string(data), "\n"),
+		indent: 2,
+		eol:    "\n",
+	}
+	if e.lines[len(e.lines)-1] == "" {
+		e.lines = e.lines[:len(e.lines)-1]
+	}
+	if len(e.lines) > 0 && strings.HasSuffix(e.lines[0], "\r\n") {
+		e.eol = "\r\n"
+	}
+	e.end = len(e.lines)
+
+	var doc yaml.Node
+	if err = yaml.NewDecoder(bytes.NewReader(data)).Decode(&doc); err != nil {
+		if err == io.EOF {
+			err = nil
+		}
+		return
+	}
+
+	if len(doc.Content) > 0 {
+		e.root = doc.Content[0]
+
+		for i := e.root.Line; i < len(e.lines); i++ {
+			if isDocumentMarker(e.lines[i]) {
+				e.end = i
+				break
+			}
+		}
+
+		if step := detectIndent(e.root); step > 0 {
+			e.indent = step
+		}
+	}
+	return
+}
+
+func (e *editor) edit(path string, value *yaml.Node) error {
+	keys := strings.Split(path, ".")
+
+	switch {
+	case e.root == nil:
+		if value != nil {
+			e.splice(e.end, e.end, e.render(keys[0], nest(keys[1:], value), 0))
+		}
+		return nil
+
+	case e.root.Kind == yaml.MappingNode && e.root.Style&yaml.FlowStyle != 0:
+		if err := editNode(e.root, path, keys, value); err != nil {
+			return err
+		}
+		text := e.encode(e.root)
+		first := e.root.Line - 1
+		e.splice(first, e.lastLine(1, first, e.end)+1, text)
+		return nil
+
+	default:
+		return e.editMapping(e.root, e.end, path, keys, value)
+	}
+}
+
+// editMapping replaces or removes (if value is nil) a value in a block
+// mapping.  The boundary is the index of the line after the mapping's last
+// possible line.
+func (e *editor) editMapping(mapping *yaml.Node, boundary int, path string, keys []string, value *yaml.Node) error {
+	if mapping.Kind != yaml.MappingNode {
+		return fmt.Errorf("config key in file is not a mapping: %q", path)
+	}
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i]
+		old := mapping.Content[i+1]
+		if key.Value != keys[0] {
+			continue
+		}
+
+		end := boundary
+		if i+2 < len(mapping.Content) {
+			end = mapping.Content[i+2].Line - 1
+		}
+
+		switch {
+		case len(keys) > 1 && value == nil && emptiedBy(old, keys[1:]):
+			return e.replace(key, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Style: yaml.FlowStyle}, end)
+
+		case len(keys) > 1 && old.Kind == yaml.ScalarNode && old.Tag == "!!null":
+			if value == nil {
+				return nil
+			}
+			return e.replace(key, nest(keys[1:], value), end)
+
+		case len(keys) > 1 && old.Kind == yaml.MappingNode && old.Style&yaml.FlowStyle != 0:
+			if err := editNode(old, path, keys[1:], value); err != nil {
+				return err
+			}
+			return e.replace(key, old, end)
+
+		case len(keys) > 1:
+			return e.editMapping(old, end, path, keys[1:], value)
+
+		case value == nil:
+			return e.remove(key, end)
+
+		default:
+			if old.Style&yaml.FlowStyle != 0 && value.Kind != yaml.ScalarNode {
+				value.Style |= yaml.FlowStyle
+			}
+			return e.replace(key, value, end)
+		}
+	}
+
+	if value == nil {
+		return nil
+	}
+
+	key := mapping.Content[len(mapping.Content)-2]
+	at := e.lastLine(key.Column, key.Line-1, boundary) + 1
+	e.splice(at, at, e.render(keys[0], nest(keys[1:], value), key.Column-1))
+	return nil
+}
+
+// replace the value of a key.  A comment on the key's line is preserved.
+func (e *editor) replace(key, value *yaml.Node, boundary int) error {
+	first := key.Line - 1
+	last := e.lastLine(key.Column, first, boundary)
+
+	line := strings.TrimRight(e.lines[first], "\r\n")
+	colon := findColon(line, columnOffset(line, key.Column))
+	if colon < 0 {
+		return fmt.Errorf("config key in file has unsupported syntax: %q", key.Value)
+	}
+	comment := line[findComment(line, colon):]
+	value.HeadComment = ""
+	value.LineComment = ""
+	value.FootComment = ""
+
+	// Render with a placeholder key and keep the original key.
+	text := e.render("x", value, key.Column-1)
+	tail := strings.TrimPrefix(text[0], strings.Repeat(" ", key.Column-1)+"x:")
+	text[0] = line[:colon] + strings.TrimSuffix(tail, e.eol) + comment + e.eol
+
+	e.splice(first, last+1, text)
+	return nil
+}
+
+// remove a key, its value and its head comment.
+func (e *editor) remove(key *yaml.Node, boundary int) error {
+	first := key.Line - 1
+	last := e.lastLine(key.Column, first, boundary)
+
+	if key.HeadComment != "" {
+		for n := strings.Count(key.HeadComment, "\n") + 1; n > 0 && first > 0 && isComment(e.lines[first-1]); n-- {
+			first--
+		}
+	}
+
+	e.splice(first, last+1, nil)
+	return nil
+}
+
+// lastLine of a node which starts on the first line and ends before the
+// boundary line.  Trailing blank lines, and comment lines which are not
+// indented more than the node's column, are not part of the node.
+func (e *editor) lastLine(column, first, boundary int) int {
+	for i := boundary - 1; i > first; i-- {
+		s := strings.TrimRight(e.lines[i], "\r\n")
+		trimmed := strings.TrimLeft(s, " ")
+		if trimmed != "" && (!strings.HasPrefix(trimmed, "#") || len(s)-len(trimmed) >= column) {
+			return i
+		}
+	}
+	return first
+}
+
+// splice replaces lines [first, last) with new lines.
+func (e *editor) splice(first, last int, text []string) {
+	if first > 0 && !strings.HasSuffix(e.lines[first-1], "\n") {
+		e.lines[first-1] += e.eol
+	}
+
+	lines := append([]string{}, e.lines[:first]...)
+	lines = append(lines, text...)
+	e.lines = append(lines, e.lines[last:]...)
+}
+
+// render a key and its value as lines with the given indentation.
+func (e *editor) render(key string, value *yaml.Node, indent int) []string {
+	mapping := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Tag:  "!!map",
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			value,
+		},
+	}
+	text := e.encode(mapping)
+	for i := range text {
+		text[i] = strings.Repeat(" ", indent) + strings.TrimSuffix(text[i], "\n") + e.eol
+	}
+	return text
+}
+
+func (e *editor) encode(node *yaml.Node) []string {
+	b := new(bytes.Buffer)
+	encoder := yaml.NewEncoder(b)
+	encoder.SetIndent(e.indent)
+	if err := encoder.Encode(node); err != nil {
+		panic(err) // Node was encoded from a value or decoded from YAML.
+	}
+	if err := encoder.Close(); err != nil {
+		panic(err)
+	}
+	lines := strings.SplitAfter(b.String(), "\n")
+	return lines[:len(lines)-1]
+}
+
+// nest a value in new mappings for the keys.
+func nest(keys []string, value *yaml.Node) *yaml.Node {
+	for i := len(keys) - 1; i >= 0; i-- {
+		value = &yaml.Node{
+			Kind: yaml.MappingNode,
+			Tag:  "!!map",
+			Content: []*yaml.Node{
+				{Kind: yaml.ScalarNode, Tag: "!!str", Value: keys[i]},
+				value,
+			},
+		}
+	}
+	return value
+}
+
+// emptiedBy returns true if removing the key path leaves the mapping empty.
+func emptiedBy(mapping *yaml.Node, keys []string) bool {
+	if mapping.Kind != yaml.MappingNode || len(mapping.Content) != 2 || mapping.Content[0].Value != keys[0] {
+		return false
+	}
+	return len(keys) == 1 || emptiedBy(mapping.Content[1], keys[1:])
+}
+
+// detectIndent finds the indentation step of the first nested block mapping.
+func detectIndent(node *yaml.Node) int {
+	if node.Kind != yaml.MappingNode || node.Style&yaml.FlowStyle != 0 {
+		return 0
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		value := node.Content[i+1]
+
+		if value.Kind == yaml.MappingNode && value.Style&yaml.FlowStyle == 0 && value.Column > key.Column {
+			return value.Column - key.Column
+		}
+		if step := detectIndent(value); step > 0 {
+			return step
+		}
+	}
+	return 0
+}
+
+// editNode replaces or removes (if value is nil) a mapping value in a node
+// tree.  It's used for flow mappings, which are rewritten as a whole.
+func editNode(mapping *yaml.Node, path string, keys []string, value *yaml.Node) error {
+	if mapping.Kind != yaml.MappingNode {
+		return fmt.Errorf("config key in file is not a mapping: %q", path)
+	}
+
+	key := keys[0]
+
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+
+		old := mapping.Content[i+1]
+
+		switch {
+		case len(keys) > 1:
+			return editNode(old, path, keys[1:], value)
+
+		case value == nil:
+			mapping.Content = append(mapping.Content[:i], mapping.Content[i+2:]...)
+
+		default:
+			value.Style |= yaml.FlowStyle
+			mapping.Content[i+1] = value
+		}
+		return nil
+	}
+
+	if value == nil {
+		return nil
+	}
+
+	value.Style |= yaml.FlowStyle
+	child := nest(keys[1:], value)
+	child.Style |= yaml.FlowStyle
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+	return nil
+}
+
+// columnOffset converts a 1-based character column to a byte offset.
+func columnOffset(line string, column int) int {
+	n := 1
+	for i := range line {
+		if n == column {
+			return i
+		}
+		n++
+	}
+	return len(line)
+}
+
+// findColon returns the offset after the mapping value indicator which
+// follows the key at the given offset, or -1.
+func findColon(line string, offset int) int {
+	if offset < len(line) && (line[offset] == '"' || line[offset] == '\'') {
+		offset = skipQuoted(line, offset)
+		if offset < 0 {
+			return -1
+		}
+	}
+
+	for i := offset; i < len(line); i++ {
+		if line[i] == ':' && (i+1 == len(line) || line[i+1] == ' ' || line[i+1] == '\t') {
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// findComment returns the offset of the whitespace preceding a comment which
+// starts after the offset, or the length of the line.
+func findComment(line string, offset int) int {
+	for i := offset; i < len(line); i++ {
+		switch c := line[i]; {
+		case (c == '"' || c == '\'') && (i == offset || strings.IndexByte(" \t[{,:", line[i-1]) >= 0):
+			if i = skipQuoted(line, i); i < 0 {
+				return len(line)
+			}
+			i--
+
+		case c == '#' && i > offset && (line[i-1] == ' ' || line[i-1] == '\t'):
+			return len(strings.TrimRight(line[:i], " \t"))
+		}
+	}
+	return len(line)
+}
+
+// skipQuoted returns the offset after a quoted scalar, or -1 if it doesn't
+// end on the line.
+func skipQuoted(line string, offset int) int {
+	quote := line[offset]
+
+	for i := offset + 1; i < len(line); i++ {
+		switch {
+		case quote == '"' && line[i] == '\\':
+			i++
+
+		case line[i] == quote:
+			if quote == '\'' && i+1 < len(line) && line[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return -1
+}
+
+func isComment(line string) bool {
+	return strings.HasPrefix(strings.TrimLeft(line, " \t"), "#")
+}
+
+func isDocumentMarker(line string) bool {
+	for _, marker := range []string{"---", "..."} {
+		if strings.HasPrefix(line, marker) && (len(line) == 3 || strings.IndexByte(" \t\r\n", line[3]) >= 0) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testEditYAML = `# Operator notes.
+bar: 1 # The answer.
+
+# Foo section.
+foo:
+  key10: hello
+  key11: [a, b]
+  key2: 2
+`
+
+const testEditedYAML = `# Operator notes.
+bar: 42 # The answer.
+
+# Foo section.
+foo:
+  key10: hello
+  key11: [c]
+baz:
+  interval: 1m0s
+`
+
+func TestEditFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+
+	if err := ioutil.WriteFile(filename, []byte(testEditYAML), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := EditFile(filename, "bar", 42); err != nil {
+		t.Fatal(err)
+	}
+	if err := EditFile(filename, "foo.key11", []string{"c"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := EditFile(filename, "baz.interval", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnsetInFile(filename, "foo.key2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnsetInFile(filename, "foo.nonexistent"); err != nil {
+		t.Fatal(err)
+	}
+	if EditFile(filename, "bar.x", 1) == nil {
+		t.Fail()
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); s != testEditedYAML {
+		t.Error(s)
+	}
+
+	c := new(testConfig)
+	if err := ReadFile(filename, c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bar != 42 || c.Baz.Interval != time.Minute {
+		t.Errorf("%#v", c)
+	}
+
+	newFilename := filepath.Join(filepath.Dir(filename), "new.yaml")
+
+	if err := EditFile(newFilename, "foo.key1", true); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := ioutil.ReadFile(newFilename); err != nil {
+		t.Fatal(err)
+	} else if s := string(data); s != "foo:\n  key1: true\n" {
+		t.Error(s)
+	}
+}
+
+func TestEditFilePreserve(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, x := range []struct {
+		input  string
+		path   string
+		value  interface{}
+		output string
+	}{
+		{
+			"bar: 1\n---\nprofile: prod\nbar: 3\n",
+			"bar", 2,
+			"bar: 2\n---\nprofile: prod\nbar: 3\n",
+		},
+		{
+			"foo:\n    key1: true   # Comment.\n\n    key2: 2\n\n# Trailer.\n",
+			"foo.key1", false,
+			"foo:\n    key1: false   # Comment.\n\n    key2: 2\n\n# Trailer.\n",
+		},
+		{
+			"foo:\n    key2: 2\n\nbar: 1\n",
+			"baz.embed1.embedded", true,
+			"foo:\n    key2: 2\n\nbar: 1\nbaz:\n    embed1:\n        embedded: true\n",
+		},
+		{
+			"foo:\n  key11:\n  - a\n  - b\n  # Key2.\n  key2: 2\n",
+			"foo.key11", "c",
+			"foo:\n  key11: c\n  # Key2.\n  key2: 2\n",
+		},
+		{
+			"foo:\nbar: 1\n",
+			"foo.key2", 7,
+			"foo:\n  key2: 7\nbar: 1\n",
+		},
+		{
+			"foo: ~ # Empty.\nbar: 1\n",
+			"foo.key2", 7,
+			"foo: # Empty.\n  key2: 7\nbar: 1\n",
+		},
+		{
+			"foo: {key1: true, key2: 2} # Flow.\nbar: 1\n",
+			"foo.key10", "x",
+			"foo: {key1: true, key2: 2, key10: x} # Flow.\nbar: 1\n",
+		},
+		{
+			"\"foo\": 'it''s' # Quoted.\n",
+			"foo", "#",
+			"\"foo\": '#' # Quoted.\n",
+		},
+		{
+			"foo:\n  # Only key.\n  key2: 2\nbar: 1\n",
+			"foo.key2", nil,
+			"foo: {}\nbar: 1\n",
+		},
+		{
+			"bar: 1\n# Foo.\nfoo: x\n# Baz.\nbaz: y\n",
+			"foo", nil,
+			"bar: 1\n# Baz.\nbaz: y\n",
+		},
+	} {
+		filename := filepath.Join(dir, "config.yaml")
+
+		if err := ioutil.WriteFile(filename, []byte(x.input), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		var err error
+		if x.value == nil {
+			err = UnsetInFile(filename, x.path)
+		} else {
+			err = EditFile(filename, x.path, x.value)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := string(data); s != x.output {
+			t.Errorf("%s: %q", x.path, s)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// KeySize is the length of an encryption key in bytes.
+const KeySize = 32
+
+const (
+	encryptedPrefix = "ENC[aes256gcm:"
+	encryptedSuffix = "]"
+)
+
+// GenerateKey creates a random encryption key.
+func GenerateKey() (key []byte, err error) {
+	key = make([]byte, KeySize)
+	_, err = rand.Read(key)
+	return
+}
+
+// ReadKeyFile reads a base64-encoded encryption key from a file.
+func ReadKeyFile(filename string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeKey(string(data))
+}
+
+// KeyFromEnv decodes a base64-encoded encryption key from an environment
+// variable.
+func KeyFromEnv(name string) ([]byte, error) {
+	s, found := os.LookupEnv(name)
+	if !found {
+		return nil, fmt.Errorf("encryption key variable is not set: %s", name)
+	}
+
+	return decodeKey(s)
+}
+
+// EncodeKey as base64 for storage in a key file or an environment variable.
+func EncodeKey(key []byte) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+func decodeKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption key size is %d bytes, expected %d", len(key), KeySize)
+	}
+	return key, nil
+}
+
+// Encrypt a value for the given path.  The result is an envelope of the form
+// "ENC[...]", which can be stored as the value of the path in a YAML file (see
+// SetKey).
+// The ciphertext is authenticated together with the path, so it cannot be
+// moved to another key.  AES-256-GCM is used.
+func Encrypt(key []byte, path, plaintext string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), []byte(path))
+
+	return encryptedPrefix + base64.StdEncoding.EncodeToString(sealed) + encryptedSuffix, nil
+}
+
+var (
+	keyLock sync.Mutex
+	keys    = make(map[interface{}]cipher.AEAD)
+)
+
+// SetKey sets the encryption key of the configuration object.  Values
+// encrypted with Encrypt are decrypted when YAML is read into the object.
+// Encrypted values are accepted only in Secret fields, so that the plaintext
+// isn't written out by accident (see IncludeSecrets).  Reading an encrypted
+// value fails if the key hasn't been set, if the value is in a field of
+// another type, or if it was encrypted for another path.
+func SetKey(config interface{}, key []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	keyLock.Lock()
+	defer keyLock.Unlock()
+
+	keys[config] = aead
+	return nil
+}
+
+func configKey(config interface{}) cipher.AEAD {
+	keyLock.Lock()
+	defer keyLock.Unlock()
+
+	return keys[config]
+}
+
+// shareKey makes the key of the configuration object available to a
+// temporary copy of it until done is called.
+func shareKey(config, tmp interface{}) (done func()) {
+	keyLock.Lock()
+	defer keyLock.Unlock()
+
+	aead, found := keys[config]
+	if !found {
+		return func() {}
+	}
+
+	keys[tmp] = aead
+
+	return func() {
+		keyLock.Lock()
+		defer keyLock.Unlock()
+
+		delete(keys, tmp)
+	}
+}
+
+// decryptValues decrypts the encrypted values of the configuration in place.
+func decryptValues(config interface{}) (err error) {
+	defer func() {
+		err = asError(recover())
+	}()
+
+	aead := configKey(config)
+
+	walk("", reflect.ValueOf(config), func(path string, _ reflect.StructField, value reflect.Value) {
+		switch value.Kind() {
+		case reflect.String:
+			decryptValue(aead, path, value)
+
+		case reflect.Slice:
+			for i := 0; i < value.Len(); i++ {
+				decryptValue(aead, path, value.Index(i))
+			}
+		}
+	})
+	return
+}
+
+func decryptValue(aead cipher.AEAD, path string, value reflect.Value) {
+	s := value.String()
+	if !strings.HasPrefix(s, encryptedPrefix) || !strings.HasSuffix(s, encryptedSuffix) {
+		return
+	}
+
+	if !isSecret(value) {
+		panic(fmt.Errorf("encrypted config value in non-secret field: %q", path))
+	}
+	if aead == nil {
+		panic(fmt.Errorf("encrypted config value but no key: %q", path))
+	}
+
+	value.SetString(decrypt(aead, path, s))
+}
+
+func decrypt(aead cipher.AEAD, path, s string) string {
+	sealed, err := base64.StdEncoding.DecodeString(s[len(encryptedPrefix) : len(s)-len(encryptedSuffix)])
+	if err != nil || len(sealed) < aead.NonceSize() {
+		panic(fmt.Errorf("malformed encrypted config value: %q", path))
+	}
+
+	nonce := sealed[:aead.NonceSize()]
+
+	plaintext, err := aead.Open(nil, nonce, sealed[len(nonce):], []byte(path))
+	if err != nil {
+		panic(fmt.Errorf("config value decryption failed: %q", path))
+	}
+
+	return string(plaintext)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption key size is %d bytes, expected %d", len(key), KeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncrypt(t *testing.T) {
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	if err := ioutil.WriteFile(keyFile, []byte(EncodeKey(key)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	password, err := Encrypt(key, "db.password", "hunter2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(password, "ENC[") || strings.Contains(password, "hunter2") {
+		t.Error(password)
+	}
+
+	user, err := Encrypt(key, "db.user", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	input := "db:\n  password: " + password + "\n"
+
+	if err := Read(strings.NewReader(input), new(secretConfig)); err == nil {
+		t.Error("read encrypted value without key")
+	}
+
+	key2, err := ReadKeyFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := new(secretConfig)
+	if err := SetKey(c, key2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Read(strings.NewReader(input), c); err != nil {
+		t.Fatal(err)
+	}
+	if c.DB.Password != "hunter2" {
+		t.Error(string(c.DB.Password))
+	}
+
+	if err := Read(strings.NewReader("db:\n  user: "+user+"\n"), c); err == nil {
+		t.Error("decrypted into non-secret field")
+	}
+
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+	if err := ioutil.WriteFile(filename, []byte(input), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c = new(secretConfig)
+	if err := SetKey(c, key2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ReadFileTx(filename, c); err != nil {
+		t.Fatal(err)
+	}
+	if c.DB.Password != "hunter2" {
+		t.Error(string(c.DB.Password))
+	}
+
+	t.Setenv("CONFIG_TEST_KEY", EncodeKey(key))
+
+	key3, err := KeyFromEnv("CONFIG_TEST_KEY")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type movedConfig struct {
+		Password Secret
+	}
+
+	moved := new(movedConfig)
+	if err := SetKey(moved, key3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Read(strings.NewReader("password: "+password+"\n"), moved); err == nil {
+		t.Error("decrypted value moved to another path")
+	}
+
+	otherKey, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c = new(secretConfig)
+	if err := SetKey(c, otherKey); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Read(strings.NewReader(input), c); err == nil {
+		t.Error("decrypted with another key")
+	}
+
+	if _, err := KeyFromEnv("CONFIG_TEST_UNDEFINED"); err == nil {
+		t.Fail()
+	}
+	if err := SetKey(c, key[:16]); err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// Expand references in the string values of the configuration object.  It
+// should be called after all files and flags have been applied.  The
+// configuration is not modified unless all references can be expanded.
+//
+// Reference syntax:
+//
+//	${env:NAME}        value of an environment variable
+//	${file:/path/name} contents of a file without trailing newline
+//	${path.to.key}     value of another configuration field
+//	$${                literal "${"
+//
+// Referenced configuration fields are expanded recursively.  It's an error if
+// an environment variable or a configuration key is undefined, or if the
+// references form a cycle.
+func Expand(config interface{}) error {
+	return transact(config, func(tmp interface{}) (err error) {
+		defer func() {
+			err = asError(recover())
+		}()
+
+		e := &expander{
+			config:   tmp,
+			expanded: make(map[string]string),
+		}
+
+		walk("", reflect.ValueOf(tmp), func(path string, _ reflect.StructField, value reflect.Value) {
+			switch value.Kind() {
+			case reflect.String:
+				value.SetString(e.path(path))
+
+			case reflect.Slice:
+				for i := 0; i < value.Len(); i++ {
+					item := value.Index(i)
+					item.SetString(e.expand(path, item.String()))
+				}
+			}
+		})
+		return
+	})
+}
+
+type expander struct {
+	config   interface{}
+	expanded map[string]string
+	active   []string
+}
+
+// path returns the expanded value of a configuration field.
+func (e *expander) path(path string) string {
+	if s, found := e.expanded[path]; found {
+		return s
+	}
+
+	for i, x := range e.active {
+		if x == path {
+			panic(fmt.Errorf("config reference cycle: %s -> %s", strings.Join(e.active[i:], " -> "), path))
+		}
+	}
+
+	node := lookup(e.config, path)
+
+	var s string
+
+	switch node.Kind() {
+	case reflect.String:
+		e.active = append(e.active, path)
+		s = e.expand(path, node.String())
+		e.active = e.active[:len(e.active)-1]
+
+	case reflect.Slice, reflect.Struct, reflect.Ptr:
+		panic(fmt.Errorf("config reference to non-scalar key: %q", path))
+
+	default:
+		s = fmt.Sprint(node.Interface())
+	}
+
+	e.expanded[path] = s
+	return s
+}
+
+// expand the references in the value of a configuration field.
+func (e *expander) expand(path, s string) string {
+	var b strings.Builder
+
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+
+		if i > 0 && s[i-1] == '$' {
+			b.WriteString(s[:i-1])
+			b.WriteString("${")
+			s = s[i+2:]
+			continue
+		}
+
+		b.WriteString(s[:i])
+		s = s[i+2:]
+
+		end := strings.Index(s, "}")
+		if end < 0 {
+			panic(fmt.Errorf("%s: unterminated config reference", path))
+		}
+
+		b.WriteString(e.resolve(path, s[:end]))
+		s = s[end+1:]
+	}
+
+	b.WriteString(s)
+	return b.String()
+}
+
+func (e *expander) resolve(path, ref string) string {
+	switch {
+	case strings.HasPrefix(ref, "env:"):
+		name := ref[4:]
+		value, found := os.LookupEnv(name)
+		if !found {
+			panic(fmt.Errorf("%s: undefined environment variable: %q", path, name))
+		}
+		return value
+
+	case strings.HasPrefix(ref, "file:"):
+		data, err := ioutil.ReadFile(ref[5:])
+		if err != nil {
+			panic(fmt.Errorf("%s: %v", path, err))
+		}
+		return strings.TrimRight(string(data), "\r\n")
+
+	default:
+		return e.path(ref)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "secret")
+	if err := ioutil.WriteFile(secret, []byte("password\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("CONFIG_TEST_EXPAND", "env")
+
+	c := new(testConfig)
+	c.Bar = 10
+	c.Foo.Key10 = "${env:CONFIG_TEST_EXPAND}/${baz.quux.key_a}"
+	c.Foo.Key11 = []string{"${file:" + secret + "}", "$${bar}", "$$${bar}"}
+	c.Baz.Quux.Key_a = "${bar}-${bar}"
+
+	if err := Expand(c); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Foo.Key10 != "env/10-10" || c.Baz.Quux.Key_a != "10-10" {
+		t.Errorf("%#v", c)
+	}
+	if !reflect.DeepEqual(c.Foo.Key11, []string{"password", "${bar}", "$${bar}"}) {
+		t.Error(c.Foo.Key11)
+	}
+
+	for value, message := range map[string]string{
+		"${foo.key10}":                 "cycle: foo.key10 -> foo.key10",
+		"${baz.quux.key_a}":            "cycle: foo.key10 -> baz.quux.key_a -> foo.key10",
+		"${env:CONFIG_TEST_UNDEFINED}": "undefined environment variable",
+		"${nonexistent}":               "unknown config key",
+		"${foo.key11}":                 "non-scalar",
+		"${bar":                        "unterminated",
+	} {
+		c := new(testConfig)
+		c.Foo.Key10 = value
+		c.Baz.Quux.Key_a = "${foo.key10}"
+
+		if err := Expand(c); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: %v", value, err)
+		}
+		if c.Foo.Key10 != value {
+			t.Error("partially applied")
+		}
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// defaultPerm is used for new files.
+const defaultPerm os.FileMode = 0600
+
+// replaceFile writes data to a temporary file and renames it over the target
+// file.  If filename is a symbolic link, the link's target is replaced.  The
+// permissions and ownership of an existing file are preserved unless permSet
+// is true.
+func replaceFile(filename string, data []byte, perm os.FileMode, permSet bool) (err error) {
+	filename, err = resolveLink(filename)
+	if err != nil {
+		return
+	}
+
+	info, err := os.Stat(filename)
+	switch {
+	case err == nil:
+		if !permSet {
+			perm = info.Mode().Perm()
+		}
+
+	case os.IsNotExist(err):
+		info = nil
+		if !permSet {
+			perm = defaultPerm
+		}
+
+	default:
+		return
+	}
+
+	dir := filepath.Dir(filename)
+
+	f, err := ioutil.TempFile(dir, "."+filepath.Base(filename)+".tmp")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if f != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(data); err != nil {
+		return
+	}
+
+	if err = f.Chmod(perm); err != nil {
+		return
+	}
+
+	if info != nil {
+		if err = chownLike(f, info); err != nil {
+			return
+		}
+	}
+
+	if err = f.Sync(); err != nil {
+		return
+	}
+
+	if err = f.Close(); err != nil {
+		return
+	}
+
+	if err = os.Rename(f.Name(), filename); err != nil {
+		return
+	}
+	f = nil
+
+	return syncDir(dir)
+}
+
+// resolveLink follows symbolic links to the file which should be replaced.
+// A dangling link resolves to its target, so that the target can be created.
+func resolveLink(filename string) (string, error) {
+	for i := 0; i < 40; i++ {
+		info, err := os.Lstat(filename)
+		if err != nil {
+			if os.IsNotExist(err) {
+				err = nil
+			}
+			return filename, err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return filename, nil
+		}
+
+		target, err := os.Readlink(filename)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(filename), target)
+		}
+		filename = target
+	}
+
+	return "", fmt.Errorf("too many levels of symbolic links: %s", filename)
+}
@@ -0,0 +1,27 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package config
+
+import (
+	"os"
+)
+
+// chownLike is a no-op on this platform.
+func chownLike(f *os.File, info os.FileInfo) error {
+	return nil
+}
+
+// syncDir is a no-op on this platform.
+func syncDir(dir string) error {
+	return nil
+}
+
+// checkFileSecurity is a no-op on this platform.
+func checkFileSecurity(filename string, info os.FileInfo, secret bool) error {
+	return nil
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.yaml")
+
+	c := new(testConfig)
+	c.Bar = 1
+
+	if err := WriteFile(filename, c); err != nil {
+		t.Fatal(err)
+	}
+	checkFileMode(t, filename, 0600)
+
+	if err := os.Chmod(filename, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Bar = 2
+
+	if err := WriteFile(filename, c); err != nil {
+		t.Fatal(err)
+	}
+	checkFileMode(t, filename, 0640)
+
+	if err := WriteFile(filename, c, Perm(0604)); err != nil {
+		t.Fatal(err)
+	}
+	checkFileMode(t, filename, 0604)
+
+	c2 := new(testConfig)
+	if err := ReadFile(filename, c2); err != nil {
+		t.Fatal(err)
+	}
+	if c2.Bar != 2 {
+		t.Fail()
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, info := range infos {
+		if name := info.Name(); name != "config.yaml" {
+			t.Error(name)
+		}
+	}
+}
+
+func TestWriteFileSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.yaml")
+	link := filepath.Join(dir, "config.yaml")
+
+	if err := os.Symlink("target.yaml", link); err != nil {
+		t.Fatal(err)
+	}
+
+	c := new(testConfig)
+	c.Bar = 1
+
+	if err := WriteFile(link, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := EditFile(link, "bar", 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Lstat(link); err != nil {
+		t.Fatal(err)
+	} else if info.Mode()&os.ModeSymlink == 0 {
+		t.Error("symbolic link was replaced")
+	}
+
+	c2 := new(testConfig)
+	if err := ReadFile(target, c2); err != nil {
+		t.Fatal(err)
+	}
+	if c2.Bar != 2 {
+		t.Fail()
+	}
+}
+
+func TestEditFileConcurrently(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+
+	const n = 20
+
+	var wg sync.WaitGroup
+	wg.Add(n)
+
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			if err := EditFile(filename, fmt.Sprintf("key%d", i), i); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != n {
+		t.Errorf("%d lines:\n%s", lines, data)
+	}
+}
+
+func TestReadFileSecure(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFiles(t, dir, map[string]string{
+		"config.yaml":  "db:\n  user: admin\n",
+		"secret.yaml":  "db:\n  password: hunter2\n",
+		"include.yaml": "include:\n- config.yaml\n",
+	})
+
+	chmod := func(name string, mode os.FileMode) {
+		t.Helper()
+		if err := os.Chmod(filepath.Join(dir, name), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, x := range []struct {
+		name   string
+		mode   os.FileMode
+		secret bool
+		ok     bool
+	}{
+		{"config.yaml", 0600, false, true},
+		{"config.yaml", 0644, false, true},
+		{"config.yaml", 0644, true, false},
+		{"config.yaml", 0664, false, false},
+		{"config.yaml", 0646, false, false},
+		{"secret.yaml", 0640, true, true},
+		{"secret.yaml", 0604, true, false},
+	} {
+		chmod(x.name, x.mode)
+
+		var c interface{} = &struct{ DB struct{ User string } }{}
+		if x.secret {
+			c = new(secretConfig)
+		}
+
+		err := ReadFileSecure(filepath.Join(dir, x.name), c)
+		if (err == nil) != x.ok {
+			t.Errorf("%s %o secret=%v: %v", x.name, x.mode, x.secret, err)
+		}
+	}
+
+	chmod("config.yaml", 0644)
+
+	if err := ReadFileSecure(filepath.Join(dir, "config.yaml"), &struct{ DB *struct{ Password Secret } }{}); err == nil {
+		t.Error("nil secret section was not detected")
+	}
+
+	chmod("config.yaml", 0666)
+	chmod("include.yaml", 0600)
+
+	if err := ReadFileSecure(filepath.Join(dir, "include.yaml"), new(secretConfig)); err == nil || !strings.Contains(err.Error(), "include chain") {
+		t.Error(err)
+	}
+
+	if err := ReadFile(filepath.Join(dir, "include.yaml"), new(secretConfig)); err != nil {
+		t.Error(err)
+	}
+
+	chmod("config.yaml", 0600)
+	chmod("secret.yaml", 0600)
+
+	r := SecureFileReader(new(secretConfig))
+
+	for _, arg := range []string{dir, filepath.Join(dir, "*.yaml"), "?" + filepath.Join(dir, "nonexistent.yaml")} {
+		if err := r.Set(arg); err != nil {
+			t.Errorf("%s: %v", arg, err)
+		}
+	}
+
+	chmod("config.yaml", 0666)
+
+	if err := r.Set(dir); err == nil {
+		t.Error("directory with unsafe file was read")
+	}
+
+	chmod("config.yaml", 0600)
+
+	if err := os.Chmod(dir, 0770); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ReadFileSecure(filepath.Join(dir, "config.yaml"), new(secretConfig)); err == nil || !strings.Contains(err.Error(), "directory") {
+		t.Error(err)
+	}
+
+	if err := os.Chmod(dir, os.ModeSticky|0777); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ReadFileSecure(filepath.Join(dir, "config.yaml"), new(secretConfig)); err != nil {
+		t.Error(err)
+	}
+}
+
+func checkFileMode(t *testing.T, filename string, mode os.FileMode) {
+	t.Helper()
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != mode {
+		t.Errorf("mode is %o, expected %o", perm, mode)
+	}
+}
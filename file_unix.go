@@ -0,0 +1,102 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// chownLike changes the ownership of a file to match another file.
+func chownLike(f *os.File, info os.FileInfo) error {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	uid := int(st.Uid)
+	gid := int(st.Gid)
+
+	if fi, err := f.Stat(); err == nil {
+		if cur, ok := fi.Sys().(*syscall.Stat_t); ok && int(cur.Uid) == uid && int(cur.Gid) == gid {
+			return nil
+		}
+	}
+
+	return f.Chown(uid, gid)
+}
+
+func syncDir(dir string) (err error) {
+	f, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	return f.Sync()
+}
+
+// checkFileSecurity of a file which is about to be read.  The directories
+// leading to the file are checked too.
+func checkFileSecurity(filename string, info os.FileInfo, secret bool) error {
+	mode := info.Mode().Perm()
+
+	if mode&0022 != 0 {
+		return errors.New("config file is writable by group or others")
+	}
+
+	if secret && mode&0004 != 0 {
+		return errors.New("config file with secrets is readable by others")
+	}
+
+	if !hasSafeOwner(info) {
+		return errors.New("config file is not owned by current user or root")
+	}
+
+	path, err := filepath.EvalSymlinks(filename)
+	if err != nil {
+		return err
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return err
+	}
+
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return err
+		}
+
+		if info.Mode()&0022 != 0 && info.Mode()&os.ModeSticky == 0 {
+			return fmt.Errorf("config file directory is writable by group or others: %s", dir)
+		}
+
+		if !hasSafeOwner(info) {
+			return fmt.Errorf("config file directory is not owned by current user or root: %s", dir)
+		}
+
+		if filepath.Dir(dir) == dir {
+			return nil
+		}
+	}
+}
+
+// hasSafeOwner returns true if the file is owned by the effective user or
+// root.
+func hasSafeOwner(info os.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return true
+	}
+
+	uid := int(st.Uid)
+	return uid == 0 || uid == os.Geteuid()
+}
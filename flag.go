@@ -5,27 +5,138 @@
 package config
 
 import (
+	"crypto/ed25519"
 	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 )
 
 // FileReader makes a ``dynamic value'' which reads files into the
-// configuration as it receives filenames.
+// configuration as it receives filenames.  If a filename refers to a
+// directory, its YAML files are read (see ReadDir).  The filename "-" reads
+// from standard input.  A filename containing wildcards is expanded into the
+// matching filenames in sorted order (see filepath.Match).  If a filename is
+// prefixed with "?", it's not an error if the file doesn't exist or the
+// wildcards don't match anything.
 func FileReader(config interface{}) flag.Value {
-	return fileReader{config}
+	return fileReader{config, source{}}
+}
+
+// SecureFileReader makes a ``dynamic value'' which reads files into the
+// configuration like FileReader, but only if they have safe ownership and
+// permissions.  Standard input is read without checks.
+//
+// See ReadFileSecure for details.
+func SecureFileReader(config interface{}) flag.Value {
+	return fileReader{config, source{secure: true}}
 }
 
 type fileReader struct {
 	config interface{}
+	src    source
 }
 
-func (fr fileReader) Set(filename string) error {
-	return ReadFile(filename, fr.config)
+func (fr fileReader) Set(arg string) (err error) {
+	optional := strings.HasPrefix(arg, "?")
+	if optional {
+		arg = arg[1:]
+	}
+
+	if arg == "-" {
+		return Read(os.Stdin, fr.config)
+	}
+
+	filenames := []string{arg}
+
+	if strings.ContainsAny(arg, "*?[") {
+		if filenames, err = filepath.Glob(arg); err != nil {
+			return
+		}
+		if len(filenames) == 0 && !optional {
+			return fmt.Errorf("no config files match pattern: %q", arg)
+		}
+		sort.Strings(filenames)
+	}
+
+	for _, filename := range filenames {
+		if info, e := os.Stat(filename); e == nil && info.IsDir() {
+			err = readDir(fr.src, filename, fr.config)
+		} else {
+			err = readFile(fr.src, filename, fr.config, nil)
+		}
+		if err != nil {
+			if optional && os.IsNotExist(err) {
+				err = nil
+				continue
+			}
+			return
+		}
+	}
+
+	return
 }
 
 func (fileReader) String() string {
 	return ""
 }
 
+// ProfileFileReader makes a ``dynamic value'' which reads files and the
+// selected profile into the configuration as it receives filenames.  The
+// profile is dereferenced when a filename is received, so the profile flag
+// must precede the file flags.  If the profile is empty (or the pointer is
+// nil), the profile is taken from the environment variable named by
+// ProfileEnv.
+//
+// See ReadFileProfile for details.
+func ProfileFileReader(config interface{}, profile *string) flag.Value {
+	return profileFileReader{config, profile}
+}
+
+type profileFileReader struct {
+	config  interface{}
+	profile *string
+}
+
+func (pfr profileFileReader) Set(filename string) error {
+	var profile string
+	if pfr.profile != nil {
+		profile = *pfr.profile
+	}
+	if profile == "" {
+		profile = os.Getenv(ProfileEnv)
+	}
+
+	return ReadFileProfile(filename, profile, pfr.config)
+}
+
+func (profileFileReader) String() string {
+	return ""
+}
+
+// SignedFileReader makes a ``dynamic value'' which reads signed files into the
+// configuration as it receives filenames.
+//
+// See ReadSignedFile for details.
+func SignedFileReader(config interface{}, trusted ...ed25519.PublicKey) flag.Value {
+	return signedFileReader{config, trusted}
+}
+
+type signedFileReader struct {
+	config  interface{}
+	trusted []ed25519.PublicKey
+}
+
+func (sfr signedFileReader) Set(filename string) error {
+	return ReadSignedFile(filename, sfr.config, sfr.trusted...)
+}
+
+func (signedFileReader) String() string {
+	return ""
+}
+
 // Assigner makes a ``dynamic value'' which sets fields in the configuration as
 // it receives assignment expressions.
 func Assigner(config interface{}) flag.Value {
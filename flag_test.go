@@ -8,6 +8,8 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -109,3 +111,80 @@ func TestAssigner(t *testing.T) {
 		})
 	}
 }
+
+func TestFileReader(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{
+		"config.yaml":      "bar: 1\n",
+		"conf.d/10-a.yaml": "foo:\n  key1: true\n",
+		"conf.d/20-b.yaml": "bar: 2\n",
+	})
+
+	c := new(testConfig)
+
+	s := flag.NewFlagSet("test", flag.ContinueOnError)
+	s.Var(FileReader(c), "f", "read config from YAML files")
+
+	if err := s.Parse([]string{
+		"-f", filepath.Join(dir, "config.yaml"),
+		"-f", filepath.Join(dir, "conf.d"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Bar != 2 || !c.Foo.Key1 {
+		t.Errorf("%#v", c)
+	}
+}
+
+func TestFileReaderArgs(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{
+		"conf/b.yaml": "bar: 2\n",
+		"conf/a.yaml": "bar: 1\nfoo:\n  key1: true\n",
+		"conf/c.txt":  "bar: [\n",
+		"stdin.yaml":  "foo:\n  key2: 4\n",
+	})
+
+	stdin, err := os.Open(filepath.Join(dir, "stdin.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stdin.Close()
+
+	defer func(orig *os.File) {
+		os.Stdin = orig
+	}(os.Stdin)
+	os.Stdin = stdin
+
+	c := new(testConfig)
+
+	s := flag.NewFlagSet("test", flag.ContinueOnError)
+	s.SetOutput(ioutil.Discard)
+	s.Var(FileReader(c), "f", "read config from YAML files")
+
+	if err := s.Parse([]string{
+		"-f", filepath.Join(dir, "conf/*.yaml"),
+		"-f", "-",
+		"-f", "?" + filepath.Join(dir, "nonexistent.yaml"),
+		"-f", "?" + filepath.Join(dir, "nonexistent/*.yaml"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Bar != 2 || !c.Foo.Key1 || c.Foo.Key2 != 4 {
+		t.Errorf("%#v", c)
+	}
+
+	for _, arg := range []string{
+		filepath.Join(dir, "nonexistent.yaml"),
+		filepath.Join(dir, "nonexistent/*.yaml"),
+		"?" + filepath.Join(dir, "conf/c.txt"),
+	} {
+		if err := s.Parse([]string{"-f", arg}); err == nil {
+			t.Error(arg)
+		}
+	}
+}
@@ -1,3 +1,6 @@
 module github.com/tsavola/config
 
-require gopkg.in/yaml.v2 v2.2.1
+require (
+	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
+)
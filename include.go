@@ -0,0 +1,166 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Include is the top-level YAML key which lists other files to be read
+// before the including file.  Relative filenames are resolved relative to the
+// directory of the including file.  Files read via ReadFS may include only
+// files in the same file system.  Included files may include more files,
+// but cycles are not allowed.
+//
+// Example:
+//
+//	include:
+//	- common.yaml
+//	- /etc/example/site.yaml
+//	audio:
+//	  enabled: true
+const Include = "include"
+
+func (doc *document) includes() (filenames []string, err error) {
+	var meta map[string]interface{}
+	if err = doc.unmarshal(&meta); err != nil {
+		return
+	}
+
+	x, found := meta[Include]
+	if !found {
+		return
+	}
+
+	list, ok := x.([]interface{})
+	if !ok {
+		err = fmt.Errorf("config %s value is not a list", Include)
+		return
+	}
+
+	for _, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			err = fmt.Errorf("config %s list item is not a string: %v", Include, item)
+			return
+		}
+		filenames = append(filenames, s)
+	}
+	return
+}
+
+// source of configuration files.
+type source struct {
+	fsys   fs.FS // Operating system is used if nil.
+	secure bool  // Check file ownership and permissions.
+}
+
+// readFile reads a YAML file and the files it includes.  The chain contains
+// the names of the including files.
+func readFile(src source, filename string, config interface{}, chain []string) error {
+	err := readFileIncludes(src, filename, config, chain)
+	if err != nil && len(chain) > 0 {
+		if _, ok := err.(*includeError); !ok {
+			err = &includeError{append(chain[:len(chain):len(chain)], filename), err}
+		}
+	}
+	return err
+}
+
+func readFileIncludes(src source, filename string, config interface{}, chain []string) (err error) {
+	fsys := src.fsys
+
+	for _, x := range chain {
+		if sameFile(fsys, x, filename) {
+			err = errors.New("config include cycle")
+			return
+		}
+	}
+
+	var f io.ReadCloser
+	if fsys == nil {
+		f, err = openFile(filename, config, src.secure)
+	} else {
+		f, err = fsys.Open(filename)
+	}
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	chain = append(chain[:len(chain):len(chain)], filename)
+
+	return readDocuments(f, func(doc *document) (err error) {
+		includes, err := doc.includes()
+		if err != nil {
+			return
+		}
+
+		for _, name := range includes {
+			if fsys != nil {
+				name = path.Join(path.Dir(filename), name)
+			} else if !filepath.IsAbs(name) {
+				name = filepath.Join(filepath.Dir(filename), name)
+			}
+
+			if err = readFile(src, name, config, chain); err != nil {
+				return
+			}
+		}
+
+		defer watch(config)()
+
+		return doc.decode(config)
+	})
+}
+
+func openFile(filename string, config interface{}, secure bool) (f *os.File, err error) {
+	f, err = os.Open(filename)
+	if err != nil || !secure {
+		return
+	}
+
+	info, err := f.Stat()
+	if err == nil {
+		err = checkFileSecurity(filename, info, hasSecrets(config))
+	}
+	if err != nil {
+		f.Close()
+		f = nil
+		err = fmt.Errorf("%s: %w", filename, err)
+	}
+	return
+}
+
+func sameFile(fsys fs.FS, name1, name2 string) bool {
+	if fsys != nil {
+		return path.Clean(name1) == path.Clean(name2)
+	}
+
+	abs1, err1 := filepath.Abs(name1)
+	abs2, err2 := filepath.Abs(name2)
+	return err1 == nil && err2 == nil && abs1 == abs2
+}
+
+// includeError describes the include chain which led to an error.
+type includeError struct {
+	chain []string
+	err   error
+}
+
+func (e *includeError) Error() string {
+	return fmt.Sprintf("%v (include chain: %s)", e.err, strings.Join(e.chain, " -> "))
+}
+
+func (e *includeError) Unwrap() error {
+	return e.err
+}
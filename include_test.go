@@ -0,0 +1,66 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		filename := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(filename), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filename, []byte(content), 0666); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInclude(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{
+		"main.yaml":          "include: [sub/common.yaml]\nbar: 1\n",
+		"sub/common.yaml":    "include: [nested.yaml]\nbar: 2\nfoo:\n  key2: 2\n",
+		"sub/nested.yaml":    "foo:\n  key1: true\n  key2: 3\n",
+		"cycle1.yaml":        "include: [sub/cycle2.yaml]\n",
+		"sub/cycle2.yaml":    "include: [../cycle1.yaml]\n",
+		"missing.yaml":       "include: [sub/nonexistent.yaml]\n",
+		"invalid.yaml":       "include: sub/nested.yaml\n",
+		"sub/malformed.yaml": "foo: [\n",
+		"malformed.yaml":     "include: [sub/malformed.yaml]\n",
+	})
+
+	c := new(testConfig)
+
+	if err := ReadFile(filepath.Join(dir, "main.yaml"), c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bar != 1 || !c.Foo.Key1 || c.Foo.Key2 != 2 {
+		t.Errorf("%#v", c.Foo)
+	}
+
+	for name, message := range map[string]string{
+		"cycle1.yaml":    "config include cycle (include chain: " + filepath.Join(dir, "cycle1.yaml") + " -> " + filepath.Join(dir, "sub/cycle2.yaml") + " -> " + filepath.Join(dir, "sub/../cycle1.yaml") + ")",
+		"missing.yaml":   "no such file",
+		"invalid.yaml":   "not a list",
+		"malformed.yaml": "malformed.yaml)",
+	} {
+		err := ReadFileIfExists(filepath.Join(dir, name), new(testConfig))
+		if err == nil {
+			t.Errorf("%s: no error", name)
+		} else if !strings.Contains(err.Error(), message) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
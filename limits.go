@@ -0,0 +1,168 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Limits for reading untrusted YAML input.  Zero value of a field means no
+// limit.
+type Limits struct {
+	Bytes   int64 // Size of the input stream.
+	Depth   int   // Nesting depth of mappings and lists.
+	Aliases int   // Number of alias references in the input stream.
+	Nodes   int   // Number of values in the stream after alias expansion.
+	Items   int   // Number of items in a list.
+}
+
+// ReadLimited reads YAML into the configuration like Read, but first checks
+// that the input doesn't exceed the limits.  The configuration is not
+// modified if a limit is exceeded.  Alias expansion is taken into account
+// without actually expanding the aliases, and aliases which refer to their
+// own anchors are rejected.
+//
+// The check is a separate pass over the input, made with a different YAML
+// parser than the one which decodes the configuration.  The decoder has its
+// own safeguards against excessive alias expansion.
+func ReadLimited(r io.Reader, config interface{}, limits Limits) error {
+	if limits.Bytes > 0 {
+		r = io.LimitReader(r, limits.Bytes+1)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if limits.Bytes > 0 && int64(len(data)) > limits.Bytes {
+		return fmt.Errorf("config input size exceeds limit of %d bytes", limits.Bytes)
+	}
+
+	if err := limits.check(data); err != nil {
+		return err
+	}
+
+	return Read(bytes.NewReader(data), config)
+}
+
+func (limits Limits) check(data []byte) (err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			err = asError(x)
+		}
+	}()
+
+	c := &limitChecker{
+		Limits:   limits,
+		measured: make(map[*yaml.Node]*extent),
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		var doc yaml.Node
+
+		if err := decoder.Decode(&doc); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		for _, node := range doc.Content {
+			e := c.measure(node, 0)
+
+			c.nodes = addSaturated(c.nodes, e.size)
+			if c.Nodes > 0 && c.nodes > c.Nodes {
+				panic(fmt.Errorf("config value count exceeds limit of %d (after alias expansion)", c.Nodes))
+			}
+		}
+	}
+}
+
+type limitChecker struct {
+	Limits
+	aliases  int
+	nodes    int
+	measured map[*yaml.Node]*extent // Anchored nodes; nil while being measured.
+}
+
+// extent of a YAML node after alias expansion.
+type extent struct {
+	size  int // Number of nodes (saturated).
+	depth int // Nesting depth of mappings and lists.
+}
+
+// measure a node which is nested in the given number of mappings and lists.
+// Anchored nodes are measured only once, so the work is proportional to the
+// size of the input.
+func (c *limitChecker) measure(node *yaml.Node, depth int) (e extent) {
+	switch node.Kind {
+	case yaml.AliasNode:
+		c.aliases++
+		if c.Aliases > 0 && c.aliases > c.Aliases {
+			panic(fmt.Errorf("config alias count exceeds limit of %d (line %d)", c.Aliases, node.Line))
+		}
+
+		x, found := c.measured[node.Alias]
+		if !found {
+			return c.measure(node.Alias, depth)
+		}
+		if x == nil {
+			panic(fmt.Errorf("config alias refers to its own anchor (line %d)", node.Line))
+		}
+
+		c.checkDepth(depth+x.depth, node.Line)
+		return *x
+
+	case yaml.SequenceNode:
+		if c.Items > 0 && len(node.Content) > c.Items {
+			panic(fmt.Errorf("config list length exceeds limit of %d items (line %d)", c.Items, node.Line))
+		}
+		fallthrough
+
+	case yaml.MappingNode:
+		c.checkDepth(depth+1, node.Line)
+
+		if node.Anchor != "" {
+			c.measured[node] = nil
+		}
+
+		for _, child := range node.Content {
+			x := c.measure(child, depth+1)
+			e.size = addSaturated(e.size, x.size)
+			if x.depth > e.depth {
+				e.depth = x.depth
+			}
+		}
+		e.depth++
+	}
+
+	e.size = addSaturated(e.size, 1)
+
+	if node.Anchor != "" {
+		x := e
+		c.measured[node] = &x
+	}
+	return
+}
+
+func (c *limitChecker) checkDepth(depth, line int) {
+	if c.Depth > 0 && depth > c.Depth {
+		panic(fmt.Errorf("config nesting depth exceeds limit of %d (line %d)", c.Depth, line))
+	}
+}
+
+func addSaturated(a, b int) int {
+	if sum := a + b; sum >= a {
+		return sum
+	}
+	return int(^uint(0) >> 1)
+}
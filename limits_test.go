@@ -0,0 +1,108 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const laughsYAML = `
+a: &a ["lol", "lol", "lol", "lol", "lol", "lol", "lol", "lol", "lol"]
+b: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a]
+c: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b]
+d: &d [*c, *c, *c, *c, *c, *c, *c, *c, *c]
+e: &e [*d, *d, *d, *d, *d, *d, *d, *d, *d]
+f: &f [*e, *e, *e, *e, *e, *e, *e, *e, *e]
+g: &g [*f, *f, *f, *f, *f, *f, *f, *f, *f]
+h: &h [*g, *g, *g, *g, *g, *g, *g, *g, *g]
+i: &i [*h, *h, *h, *h, *h, *h, *h, *h, *h]
+`
+
+func TestReadLimited(t *testing.T) {
+	type limitConfig struct {
+		Name  string
+		Tags  []string
+		Inner struct {
+			Value int
+		}
+	}
+
+	const input = "name: &n foo\ntags: [a, b, *n]\ninner:\n  value: 5\n"
+
+	limits := Limits{
+		Bytes:   1024,
+		Depth:   2,
+		Aliases: 1,
+		Nodes:   12,
+		Items:   3,
+	}
+
+	c := new(limitConfig)
+	if err := ReadLimited(strings.NewReader(input), c, limits); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "foo" || len(c.Tags) != 3 || c.Tags[2] != "foo" || c.Inner.Value != 5 {
+		t.Errorf("%#v", c)
+	}
+
+	for _, x := range []struct {
+		limits Limits
+		err    string
+	}{
+		{Limits{Bytes: int64(len(input)) - 1}, "size"},
+		{Limits{Depth: 1}, "depth"},
+		{Limits{Aliases: 0}, ""},
+		{Limits{Nodes: 11}, "value count"},
+		{Limits{Items: 2}, "list length"},
+	} {
+		c := new(limitConfig)
+		err := ReadLimited(strings.NewReader(input), c, x.limits)
+		if x.err == "" {
+			if err != nil {
+				t.Error(err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), x.err) {
+			t.Errorf("%+v: %v", x.limits, err)
+		} else if c.Name != "" {
+			t.Errorf("%+v: config was modified", x.limits)
+		}
+	}
+
+	f, err := os.Open("/dev/zero")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := ReadLimited(f, c, Limits{Bytes: 1 << 20}); err == nil || !strings.Contains(err.Error(), "size") {
+		t.Error(err)
+	}
+
+	var laughs struct{}
+
+	if err := ReadLimited(strings.NewReader(laughsYAML), &laughs, Limits{Nodes: 1e6}); err == nil || !strings.Contains(err.Error(), "value count") {
+		t.Error(err)
+	}
+	if err := ReadLimited(strings.NewReader(laughsYAML), &laughs, Limits{Aliases: 10}); err == nil || !strings.Contains(err.Error(), "alias count") {
+		t.Error(err)
+	}
+
+	for _, limits := range []Limits{{}, {Nodes: 100}} {
+		if err := ReadLimited(strings.NewReader("a: &a {b: *a}\n"), &laughs, limits); err == nil || !strings.Contains(err.Error(), "own anchor") {
+			t.Errorf("%+v: %v", limits, err)
+		}
+	}
+
+	deep := strings.Repeat("[", 5000) + strings.Repeat("]", 5000)
+
+	if err := ReadLimited(strings.NewReader(deep), &laughs, Limits{Depth: 100}); err == nil || !strings.Contains(err.Error(), "nesting depth exceeds") {
+		t.Error(err)
+	}
+	if err := ReadLimited(strings.NewReader("a: &a [[[1]]]\nb: [[*a]]\n"), &laughs, Limits{Depth: 5}); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package config
+
+// lockFile is a no-op on this platform.
+func lockFile(filename string) (unlock func(), err error) {
+	unlock = func() {}
+	return
+}
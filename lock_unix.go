@@ -0,0 +1,40 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// lockFile acquires an exclusive advisory lock for replacing a file.  The
+// lock is held on the directory which contains the file (or the target of a
+// symbolic link), because the file itself is replaced.
+func lockFile(filename string) (unlock func(), err error) {
+	filename, err = resolveLink(filename)
+	if err != nil {
+		return
+	}
+
+	f, err := os.Open(filepath.Dir(filename))
+	if err != nil {
+		return
+	}
+
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return
+	}
+
+	unlock = func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}
+	return
+}
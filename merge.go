@@ -0,0 +1,386 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Merge overlays the fields of src which are set (have non-zero values) on
+// dst.  The objects are typically of the same type, but dst only needs to
+// have compatible fields at the paths which are set in src.  The destination
+// is not modified unless the whole merge succeeds.
+//
+// Nil pointers to structs in dst are allocated as needed.
+//
+// A string list field in dst is replaced by default.  The strategy can be
+// changed with a struct tag on the field: `merge:"append"` appends the items
+// of src, and `merge:"union"` appends only the items which dst doesn't
+// already contain.
+func Merge(dst, src interface{}) error {
+	return transact(dst, func(tmp interface{}) (err error) {
+		defer func() {
+			err = asError(recover())
+		}()
+
+		walk("", reflect.ValueOf(src), func(path string, field reflect.StructField, value reflect.Value) {
+			if value.Kind() == reflect.Slice {
+				if value.Len() > 0 {
+					mergeSlice(find(tmp, path, true), value, field.Tag.Get("merge"))
+				}
+			} else if value.Interface() != reflect.Zero(value.Type()).Interface() {
+				find(tmp, path, true).Set(value)
+			}
+		})
+		return
+	})
+}
+
+func mergeSlice(dst, src reflect.Value, strategy string) {
+	switch strategy {
+	case "", "replace":
+		dst.Set(copyValue(src))
+
+	case "append":
+		dst.Set(reflect.AppendSlice(copyValue(dst), src))
+
+	case "union":
+		result := copyValue(dst)
+
+	items:
+		for i := 0; i < src.Len(); i++ {
+			item := src.Index(i)
+			for j := 0; j < result.Len(); j++ {
+				if result.Index(j).Interface() == item.Interface() {
+					continue items
+				}
+			}
+			result = reflect.Append(result, item)
+		}
+
+		dst.Set(result)
+
+	default:
+		panic(fmt.Errorf("unknown merge strategy: %q", strategy))
+	}
+}
+
+// MergePatch applies a JSON Merge Patch (RFC 7386) document to the
+// configuration.  Object keys are field names or dotted paths.  A null value
+// resets the field or subtree to zero value.  String values are parsed
+// according to SetFromString rules when the field is not a string.  The
+// configuration is not modified unless the whole patch is applied
+// successfully.
+func MergePatch(config interface{}, patch []byte) error {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &doc); err != nil {
+		return err
+	}
+
+	return transact(config, func(tmp interface{}) (err error) {
+		defer func() {
+			err = asError(recover())
+		}()
+
+		mergePatch(reflect.ValueOf(tmp).Elem(), doc)
+		return
+	})
+}
+
+func mergePatch(struc reflect.Value, doc map[string]json.RawMessage) {
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		raw := doc[key]
+		node := find(struc.Addr().Interface(), key, !isJSONNull(raw))
+
+		switch {
+		case isJSONNull(raw):
+			zeroValues(node)
+
+		case isJSONObject(raw):
+			if node.Kind() == reflect.Ptr && node.Type().Elem().Kind() == reflect.Struct {
+				if node.IsNil() {
+					node.Set(reflect.New(node.Type().Elem()))
+				}
+				node = node.Elem()
+			}
+			if node.Kind() != reflect.Struct {
+				panic(fmt.Errorf("object value for non-struct config key: %q", key))
+			}
+			var sub map[string]json.RawMessage
+			if err := json.Unmarshal(raw, &sub); err != nil {
+				panic(err)
+			}
+			mergePatch(node, sub)
+
+		default:
+			setFromJSON(node, raw)
+		}
+	}
+}
+
+// Patch applies a JSON Patch (RFC 6902) document to the configuration.  Paths
+// may be JSON Pointers ("/audio/samplerate") or dotted paths
+// ("audio.samplerate").  String list items are addressed by index, and "-"
+// refers to the end of a list.  Removing a field resets it to zero value.
+// The configuration is not modified unless all operations succeed.
+func Patch(config interface{}, patch []byte) error {
+	var ops []struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		From  string          `json:"from"`
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return err
+	}
+
+	return transact(config, func(tmp interface{}) (err error) {
+		defer func() {
+			err = asError(recover())
+		}()
+
+		for _, op := range ops {
+			target := locate(tmp, op.Path)
+
+			switch op.Op {
+			case "add":
+				target.add(target.decode(op.Value))
+
+			case "replace":
+				target.replace(target.decode(op.Value))
+
+			case "remove":
+				target.remove()
+
+			case "test":
+				if !target.equal(target.decode(op.Value)) {
+					panic(fmt.Errorf("config patch test failed: %q", op.Path))
+				}
+
+			case "copy", "move":
+				source := locate(tmp, op.From)
+				value := duplicateValue(source.get())
+				if op.Op == "move" {
+					source.remove()
+					target = locate(tmp, op.Path) // Index may have shifted.
+				}
+				target.add(value)
+
+			default:
+				panic(fmt.Errorf("unknown config patch operation: %q", op.Op))
+			}
+		}
+		return
+	})
+}
+
+// location of a field or a string list item.
+type location struct {
+	path  string
+	node  reflect.Value // Field or list.
+	index int           // List item index, or -1.
+}
+
+func locate(config interface{}, path string) location {
+	if strings.HasPrefix(path, "/") {
+		tokens := strings.Split(path[1:], "/")
+		for i, s := range tokens {
+			tokens[i] = strings.Replace(strings.Replace(s, "~1", "/", -1), "~0", "~", -1)
+		}
+		path = strings.Join(tokens, ".")
+	}
+
+	if i := strings.LastIndex(path, "."); i > 0 {
+		if last := path[i+1:]; last == "-" || isIndex(last) {
+			list := lookup(config, path[:i])
+			if list.Kind() != reflect.Slice {
+				panic(fmt.Errorf("unknown config key: %q", path))
+			}
+
+			index := list.Len()
+			if last != "-" {
+				index, _ = strconv.Atoi(last)
+			}
+			return location{path, list, index}
+		}
+	}
+
+	node := lookup(config, path)
+	if node.Kind() == reflect.Ptr && !node.IsNil() && node.Type().Elem().Kind() == reflect.Struct {
+		node = node.Elem()
+	}
+	return location{path, node, -1}
+}
+
+func (l location) typ() reflect.Type {
+	if l.index >= 0 {
+		return l.node.Type().Elem()
+	}
+	return l.node.Type()
+}
+
+func (l location) item() reflect.Value {
+	if l.index >= l.node.Len() {
+		panic(fmt.Errorf("config list index out of range: %q", l.path))
+	}
+	return l.node.Index(l.index)
+}
+
+func (l location) get() reflect.Value {
+	if l.index >= 0 {
+		return l.item()
+	}
+	return l.node
+}
+
+func (l location) add(value reflect.Value) {
+	if l.index < 0 {
+		l.set(value)
+		return
+	}
+
+	if l.index > l.node.Len() {
+		panic(fmt.Errorf("config list index out of range: %q", l.path))
+	}
+
+	list := reflect.MakeSlice(l.node.Type(), 0, l.node.Len()+1)
+	list = reflect.AppendSlice(list, l.node.Slice(0, l.index))
+	list = reflect.Append(list, value)
+	list = reflect.AppendSlice(list, l.node.Slice(l.index, l.node.Len()))
+	l.node.Set(list)
+}
+
+func (l location) replace(value reflect.Value) {
+	if l.index < 0 {
+		l.set(value)
+	} else {
+		l.item().Set(value)
+	}
+}
+
+func (l location) set(value reflect.Value) {
+	if l.node.Kind() == reflect.Struct {
+		commit(l.node, value)
+	} else {
+		l.node.Set(value)
+	}
+}
+
+func (l location) remove() {
+	if l.index < 0 {
+		zeroValues(l.node)
+		return
+	}
+
+	l.item() // Check index.
+
+	list := reflect.MakeSlice(l.node.Type(), 0, l.node.Len()-1)
+	list = reflect.AppendSlice(list, l.node.Slice(0, l.index))
+	list = reflect.AppendSlice(list, l.node.Slice(l.index+1, l.node.Len()))
+	l.node.Set(list)
+}
+
+func (l location) equal(value reflect.Value) bool {
+	x := l.get()
+	if x.Kind() == reflect.Struct {
+		return Equal(x.Addr().Interface(), value.Addr().Interface())
+	}
+	return equalValues(x.Interface(), value.Interface())
+}
+
+// decode a JSON value of the location's type.
+func (l location) decode(raw json.RawMessage) reflect.Value {
+	if l.typ().Kind() == reflect.Struct {
+		value := duplicateValue(l.get())
+		zeroValues(value)
+
+		var doc map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &doc); err != nil {
+			panic(err)
+		}
+		mergePatch(value, doc)
+		return value
+	}
+
+	value := reflect.New(l.typ()).Elem()
+	setFromJSON(value, raw)
+	return value
+}
+
+// duplicateValue makes an addressable deep copy of a settable value or struct.
+func duplicateValue(value reflect.Value) reflect.Value {
+	if value.Kind() == reflect.Struct {
+		return duplicate(value.Addr().Interface()).Elem()
+	}
+
+	dup := reflect.New(value.Type()).Elem()
+	dup.Set(copyValue(value))
+	return dup
+}
+
+func setFromJSON(node reflect.Value, raw json.RawMessage) {
+	if kind := node.Kind(); kind != reflect.String && kind != reflect.Slice && bytes.HasPrefix(bytes.TrimSpace(raw), []byte(`"`)) {
+		var repr string
+		if err := json.Unmarshal(raw, &repr); err != nil {
+			panic(err)
+		}
+		setFromString(node, repr)
+		return
+	}
+
+	switch node.Kind() {
+	case reflect.Struct, reflect.Ptr, reflect.Map, reflect.Interface, reflect.Func, reflect.Chan:
+		panic(fmt.Errorf("unsupported field type: %s", node.Type()))
+	}
+
+	if err := json.Unmarshal(raw, node.Addr().Interface()); err != nil {
+		panic(err)
+	}
+}
+
+// zeroValues resets a settable value or all settable fields of a struct.
+func zeroValues(node reflect.Value) {
+	switch node.Kind() {
+	case reflect.Struct, reflect.Ptr:
+		walk("", node, func(_ string, _ reflect.StructField, value reflect.Value) {
+			value.Set(reflect.Zero(value.Type()))
+		})
+
+	default:
+		node.Set(reflect.Zero(node.Type()))
+	}
+}
+
+func isJSONNull(raw json.RawMessage) bool {
+	return string(bytes.TrimSpace(raw)) == "null"
+}
+
+func isJSONObject(raw json.RawMessage) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(raw), []byte("{"))
+}
+
+func isIndex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
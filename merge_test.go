@@ -0,0 +1,182 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type mergeConfig struct {
+	Name    string
+	Count   int
+	Replace []string
+	Append  []string `merge:"append"`
+	Union   []string `merge:"union"`
+	Sub     *struct {
+		Enabled bool
+		Timeout time.Duration
+	}
+}
+
+func newMergeConfig() *mergeConfig {
+	c := new(mergeConfig)
+	c.Sub = new(struct {
+		Enabled bool
+		Timeout time.Duration
+	})
+	return c
+}
+
+func TestMerge(t *testing.T) {
+	dst := newMergeConfig()
+	dst.Name = "dst"
+	dst.Count = 1
+	dst.Replace = []string{"a", "b"}
+	dst.Append = []string{"a", "b"}
+	dst.Union = []string{"a", "b"}
+
+	src := newMergeConfig()
+	src.Count = 2
+	src.Replace = []string{"b", "c"}
+	src.Append = []string{"b", "c"}
+	src.Union = []string{"b", "c"}
+	src.Sub.Enabled = true
+
+	if err := Merge(dst, src); err != nil {
+		t.Fatal(err)
+	}
+
+	if dst.Name != "dst" || dst.Count != 2 || !dst.Sub.Enabled {
+		t.Errorf("%#v", dst)
+	}
+	if !reflect.DeepEqual(dst.Replace, []string{"b", "c"}) {
+		t.Error(dst.Replace)
+	}
+	if !reflect.DeepEqual(dst.Append, []string{"a", "b", "b", "c"}) {
+		t.Error(dst.Append)
+	}
+	if !reflect.DeepEqual(dst.Union, []string{"a", "b", "c"}) {
+		t.Error(dst.Union)
+	}
+
+	other := new(struct{ Count string })
+	other.Count = "x"
+
+	if err := Merge(dst, other); err == nil {
+		t.Fail()
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	c := newMergeConfig()
+	c.Name = "name"
+	c.Count = 1
+	c.Sub.Enabled = true
+
+	if err := MergePatch(c, []byte(`{
+		"name": null,
+		"replace": ["x", "y"],
+		"sub": {"timeout": "1m"},
+		"sub.enabled": "no"
+	}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Name != "" || c.Count != 1 || c.Sub.Enabled || c.Sub.Timeout != time.Minute {
+		t.Errorf("%#v", c)
+	}
+	if !reflect.DeepEqual(c.Replace, []string{"x", "y"}) {
+		t.Error(c.Replace)
+	}
+
+	if err := MergePatch(c, []byte(`{"count": 5, "nonexistent": 1}`)); err == nil {
+		t.Fail()
+	}
+	if c.Count != 1 {
+		t.Error("partially applied")
+	}
+}
+
+func TestMergeNilPointer(t *testing.T) {
+	src := newMergeConfig()
+	src.Sub.Enabled = true
+
+	dst := new(mergeConfig)
+	if err := Merge(dst, src); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Sub == nil || !dst.Sub.Enabled || dst.Sub == src.Sub {
+		t.Errorf("%#v", dst.Sub)
+	}
+
+	dst = new(mergeConfig)
+	if err := MergePatch(dst, []byte(`{"sub": {"timeout": "1m"}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Sub == nil || dst.Sub.Timeout != time.Minute {
+		t.Errorf("%#v", dst.Sub)
+	}
+
+	dst = new(mergeConfig)
+	if err := MergePatch(dst, []byte(`{"sub.enabled": true}`)); err != nil {
+		t.Fatal(err)
+	}
+	if dst.Sub == nil || !dst.Sub.Enabled {
+		t.Errorf("%#v", dst.Sub)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	c := newMergeConfig()
+	c.Append = []string{"a", "c"}
+
+	if err := Patch(c, []byte(`[
+		{"op": "add", "path": "/append/1", "value": "b"},
+		{"op": "add", "path": "append.-", "value": "d"},
+		{"op": "test", "path": "/append", "value": ["a", "b", "c", "d"]},
+		{"op": "replace", "path": "/name", "value": "hello"},
+		{"op": "copy", "from": "/append/0", "path": "/union/-"},
+		{"op": "move", "from": "/append/3", "path": "/replace/0"},
+		{"op": "remove", "path": "/append/0"},
+		{"op": "replace", "path": "/sub", "value": {"enabled": true}},
+		{"op": "test", "path": "/sub/enabled", "value": true}
+	]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Name != "hello" || !c.Sub.Enabled {
+		t.Errorf("%#v", c)
+	}
+	if !reflect.DeepEqual(c.Append, []string{"b", "c"}) {
+		t.Error(c.Append)
+	}
+	if !reflect.DeepEqual(c.Union, []string{"a"}) {
+		t.Error(c.Union)
+	}
+	if !reflect.DeepEqual(c.Replace, []string{"d"}) {
+		t.Error(c.Replace)
+	}
+
+	if err := Patch(c, []byte(`[
+		{"op": "move", "from": "/append/0", "path": "/append/-"}
+	]`)); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.Append, []string{"c", "b"}) {
+		t.Error(c.Append)
+	}
+
+	if err := Patch(c, []byte(`[
+		{"op": "remove", "path": "/name"},
+		{"op": "test", "path": "/count", "value": 1}
+	]`)); err == nil {
+		t.Fail()
+	}
+	if c.Name != "hello" {
+		t.Error("partially applied")
+	}
+}
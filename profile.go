@@ -0,0 +1,77 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// ProfileEnv is the name of the environment variable which selects the
+// profile when it isn't specified via ProfileFileReader's flag.
+const ProfileEnv = "CONFIG_PROFILE"
+
+// ReadFileProfile reads a YAML file into the configuration and overlays a
+// named profile on it.  The profile is looked up from the file's top-level
+// "profiles" mapping, and from a sibling file which has the profile name
+// inserted before the extension (e.g. "config.prod.yaml").  If both exist,
+// the sibling file is applied last.  It's an error if neither exists.  If the
+// profile name is empty, only the base configuration is read.
+func ReadFileProfile(filename, profile string, config interface{}) (err error) {
+	if err = ReadFile(filename, config); err != nil {
+		return
+	}
+
+	if profile == "" {
+		return
+	}
+
+	if strings.ContainsAny(profile, `/\`) || strings.HasPrefix(profile, ".") {
+		err = fmt.Errorf("invalid config profile name: %q", profile)
+		return
+	}
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+
+	var doc struct {
+		Profiles map[string]yaml.MapSlice
+	}
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		return
+	}
+
+	section, found := doc.Profiles[profile]
+	if found {
+		if data, err = yaml.Marshal(section); err != nil {
+			return
+		}
+		if err = Read(bytes.NewReader(data), config); err != nil {
+			return
+		}
+	}
+
+	err = ReadFile(profileFilename(filename, profile), config)
+	if err != nil && os.IsNotExist(err) {
+		err = nil
+		if !found {
+			err = fmt.Errorf("config profile %q not found in %s", profile, filename)
+		}
+	}
+	return
+}
+
+func profileFilename(filename, profile string) string {
+	ext := filepath.Ext(filename)
+	return strings.TrimSuffix(filename, ext) + "." + profile + ext
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+const testProfileYAML = `bar: 1
+foo:
+  key10: base
+profiles:
+  dev:
+    bar: 2
+  prod:
+    bar: 3
+    foo:
+      key1: true
+`
+
+func TestReadFileProfile(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.yaml")
+
+	if err := ioutil.WriteFile(filename, []byte(testProfileYAML), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.prod.yaml"), []byte("bar: 4\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "config.test.yaml"), []byte("bar: 5\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, spec := range []struct {
+		profile string
+		bar     int
+		key1    bool
+	}{
+		{"", 1, false},
+		{"dev", 2, false},
+		{"prod", 4, true},
+		{"test", 5, false},
+	} {
+		t.Run(spec.profile, func(t *testing.T) {
+			c := new(testConfig)
+
+			if err := ReadFileProfile(filename, spec.profile, c); err != nil {
+				t.Fatal(err)
+			}
+			if c.Bar != spec.bar || c.Foo.Key1 != spec.key1 || c.Foo.Key10 != "base" {
+				t.Errorf("%#v", c.Foo)
+			}
+		})
+	}
+
+	for _, profile := range []string{"nonexistent", "../config", ".prod"} {
+		if err := ReadFileProfile(filename, profile, new(testConfig)); err == nil {
+			t.Error(profile)
+		}
+	}
+}
+
+func TestProfileFileReader(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "config.yaml")
+
+	if err := ioutil.WriteFile(filename, []byte(testProfileYAML), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	c := new(testConfig)
+
+	s := flag.NewFlagSet("test", flag.ContinueOnError)
+	profile := s.String("profile", "", "configuration profile")
+	s.Var(ProfileFileReader(c, profile), "f", "read config from YAML files")
+
+	if err := s.Parse([]string{"-profile", "dev", "-f", filename}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bar != 2 {
+		t.Fail()
+	}
+
+	t.Setenv(ProfileEnv, "prod")
+
+	c = new(testConfig)
+
+	s = flag.NewFlagSet("test", flag.ContinueOnError)
+	profile = s.String("profile", "", "configuration profile")
+	s.Var(ProfileFileReader(c, profile), "f", "read config from YAML files")
+
+	if err := s.Parse([]string{"-f", filename}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bar != 3 || !c.Foo.Key1 {
+		t.Errorf("%#v", c)
+	}
+
+	c = new(testConfig)
+
+	if err := ProfileFileReader(c, nil).Set(filename); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bar != 3 || !c.Foo.Key1 {
+		t.Errorf("%#v", c)
+	}
+}
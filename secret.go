@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"strconv"
+)
+
+const redacted = "[redacted]"
+
+var secretType = reflect.TypeOf(Secret(""))
+
+// Secret is a string which is redacted when formatted or written.  Secret
+// fields are omitted by Write and WriteFile unless the IncludeSecrets option
+// is used, and their values are not shown by PrintSettings or in Change
+// descriptions.  The value can be accessed by converting it to string.
+type Secret string
+
+// String returns a placeholder unless the secret is empty.
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+// GoString returns a placeholder unless the secret is empty.
+func (s Secret) GoString() string {
+	return "config.Secret(" + strconv.Quote(s.String()) + ")"
+}
+
+func isSecret(value reflect.Value) bool {
+	return value.Type() == secretType
+}
+
+// hasSecrets reports if the configuration type has Secret settings, including
+// ones behind nil pointers.
+func hasSecrets(config interface{}) bool {
+	return config != nil && hasSecretFields(reflect.TypeOf(config), make(map[reflect.Type]bool))
+}
+
+func hasSecretFields(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] {
+		return false
+	}
+	visited[t] = true
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		switch {
+		case field.Type == secretType:
+			return true
+
+		case field.Type.Kind() == reflect.Struct, field.Type.Kind() == reflect.Ptr && field.Type.Elem().Kind() == reflect.Struct:
+			if hasSecretFields(field.Type, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+type secretConfig struct {
+	DB struct {
+		User     string
+		Password Secret
+	}
+}
+
+func TestSecret(t *testing.T) {
+	c := new(secretConfig)
+	c.DB.User = "admin"
+	c.DB.Password = "hunter2"
+
+	for _, s := range []string{
+		fmt.Sprint(c.DB.Password),
+		fmt.Sprintf("%s %q %#v", c.DB.Password, c.DB.Password, c.DB.Password),
+		fmt.Sprintf("%v %+v %#v", c, c, c),
+		fmt.Sprint(Diff(c, new(secretConfig))),
+	} {
+		if strings.Contains(s, "hunter2") {
+			t.Error(s)
+		}
+	}
+
+	if fmt.Sprint(Secret("")) != "" {
+		t.Fail()
+	}
+
+	b := new(bytes.Buffer)
+	PrintSettings(b, c)
+	if s := b.String(); strings.Contains(s, "hunter2") || !strings.Contains(s, "db.password config.Secret ([redacted])") {
+		t.Error(s)
+	}
+
+	for _, options := range [][]WriteOption{
+		nil,
+		{Annotated()},
+		{CommentedOut()},
+		{Minimal()},
+	} {
+		b.Reset()
+		if err := Write(b, c, options...); err != nil {
+			t.Fatal(err)
+		}
+		if s := b.String(); strings.Contains(s, "hunter2") || !strings.Contains(s, "admin") {
+			t.Error(s)
+		}
+	}
+
+	b.Reset()
+	if WriteAt(b, "db.password", c) == nil {
+		t.Fail()
+	}
+	if err := WriteAt(b, "db", c); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); s != "user: admin\n" {
+		t.Error(s)
+	}
+
+	b.Reset()
+	if err := Write(b, c, IncludeSecrets()); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); s != "db:\n  user: admin\n  password: hunter2\n" {
+		t.Error(s)
+	}
+
+	c2 := new(secretConfig)
+	if err := Read(b, c2); err != nil {
+		t.Fatal(err)
+	}
+	if err := Assign(c2, "db.user=root"); err != nil {
+		t.Fatal(err)
+	}
+	if c2.DB.Password != "hunter2" || c2.DB.User != "root" {
+		t.Errorf("%q %q", string(c2.DB.Password), c2.DB.User)
+	}
+}
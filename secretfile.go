@@ -0,0 +1,112 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CredentialsDirectoryEnv is the environment variable which systemd uses to
+// pass the credentials directory to a service.
+const CredentialsDirectoryEnv = "CREDENTIALS_DIRECTORY"
+
+// ReadSecretDir reads configuration values from files in a directory, such as
+// Docker's /run/secrets.  Each file name is a dotted path (such as
+// "db.password"), and the file contents (without a trailing newline) is the
+// value representation.  Files which don't correspond to configuration
+// settings, hidden files and subdirectories are ignored.  The configuration
+// is not modified unless all files are read successfully.
+//
+// See SetFromString for parsing rules.
+func ReadSecretDir(dir string, config interface{}) (err error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	paths := settingPaths(config)
+
+	return transact(config, func(tmp interface{}) (err error) {
+		for _, info := range infos {
+			name := info.Name()
+			if info.IsDir() || strings.HasPrefix(name, ".") || !paths[name] {
+				continue
+			}
+
+			if err = setFromFile(tmp, name, filepath.Join(dir, name)); err != nil {
+				return
+			}
+		}
+		return
+	})
+}
+
+// ReadCredentials reads configuration values from the credentials directory
+// which systemd passes to a service via the $CREDENTIALS_DIRECTORY
+// environment variable.  Nothing is read if the variable is not set.
+//
+// See ReadSecretDir for details.
+func ReadCredentials(config interface{}) error {
+	dir := os.Getenv(CredentialsDirectoryEnv)
+	if dir == "" {
+		return nil
+	}
+
+	return ReadSecretDir(dir, config)
+}
+
+// ReadEnvFiles reads configuration values from files named by environment
+// variables.  The variable name for a path is formed by joining the prefix,
+// the path and the "FILE" suffix with underscores, and converting the result
+// to upper case.  E.g. with prefix "app", the value of "db.password" is read
+// from the file named by $APP_DB_PASSWORD_FILE.  The prefix may be empty.
+// The configuration is not modified unless all files are read successfully.
+//
+// See ReadSecretDir for details.
+func ReadEnvFiles(prefix string, config interface{}) error {
+	return transact(config, func(tmp interface{}) (err error) {
+		for _, s := range Settings(tmp) {
+			filename := os.Getenv(fileEnvName(prefix, s.Path))
+			if filename == "" {
+				continue
+			}
+
+			if err = setFromFile(tmp, s.Path, filename); err != nil {
+				return
+			}
+		}
+		return
+	})
+}
+
+func fileEnvName(prefix, path string) string {
+	name := strings.Replace(path, ".", "_", -1) + "_FILE"
+	if prefix != "" {
+		name = prefix + "_" + name
+	}
+	return strings.ToUpper(name)
+}
+
+func settingPaths(config interface{}) map[string]bool {
+	paths := make(map[string]bool)
+	for _, s := range Settings(config) {
+		paths[s.Path] = true
+	}
+	return paths
+}
+
+func setFromFile(config interface{}, path, filename string) (err error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+
+	repr := strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+
+	return SetFromString(config, path, repr)
+}
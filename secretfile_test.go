@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestReadSecretDir(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{
+		"db.password":      "hunter2\n",
+		"db.user":          "admin",
+		"unrelated.key":    "x",
+		".hidden":          "x",
+		"db.password.orig": "x",
+	})
+
+	t.Setenv(CredentialsDirectoryEnv, dir)
+
+	c := new(secretConfig)
+
+	if err := ReadCredentials(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.DB.Password != "hunter2" || c.DB.User != "admin" {
+		t.Errorf("%q %q", string(c.DB.Password), c.DB.User)
+	}
+
+	if ReadSecretDir(filepath.Join(dir, "nonexistent"), c) == nil {
+		t.Fail()
+	}
+}
+
+func TestReadEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{
+		"password": "hunter2\r\n",
+		"bar":      "x",
+	})
+
+	t.Setenv("TEST_DB_PASSWORD_FILE", filepath.Join(dir, "password"))
+
+	c := new(secretConfig)
+
+	if err := ReadEnvFiles("test", c); err != nil {
+		t.Fatal(err)
+	}
+	if c.DB.Password != "hunter2" || c.DB.User != "" {
+		t.Errorf("%q %q", string(c.DB.Password), c.DB.User)
+	}
+
+	t.Setenv("BAR_FILE", filepath.Join(dir, "bar"))
+
+	c2 := new(testConfig)
+
+	if ReadEnvFiles("", c2) == nil {
+		t.Fail()
+	}
+
+	t.Setenv("BAR_FILE", filepath.Join(dir, "nonexistent"))
+
+	if ReadEnvFiles("", c2) == nil {
+		t.Fail()
+	}
+}
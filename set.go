@@ -32,6 +32,8 @@ func Set(config interface{}, path string, value interface{}) (err error) {
 // type as the field.  Panic if the field doesn't exist or the types don't
 // match.
 func MustSet(config interface{}, path string, value interface{}) {
+	defer watch(config)()
+
 	lookup(config, path).Set(reflect.ValueOf(value))
 }
 
@@ -60,8 +62,12 @@ func SetFromString(config interface{}, path string, repr string) (err error) {
 //
 // See SetFromString for parsing rules.
 func MustSetFromString(config interface{}, path string, repr string) {
-	node := lookup(config, path)
+	defer watch(config)()
+
+	setFromString(lookup(config, path), repr)
+}
 
+func setFromString(node reflect.Value, repr string) {
 	switch node.Kind() {
 	case reflect.Bool:
 		setBoolFromString(node, repr)
@@ -182,6 +188,7 @@ func setSliceFromString(node reflect.Value, repr string) {
 
 // Assign a value to a field of the configuration object.  The field's path and
 // string representation are parsed from an expression of the form "path=repr".
+// An expression of the form "!path" resets the field or subtree (see Reset).
 //
 // See SetFromString for parsing rules.
 func Assign(config interface{}, expr string) (err error) {
@@ -195,10 +202,16 @@ func Assign(config interface{}, expr string) (err error) {
 
 // Assign a value to a field of the configuration object.  The field's path and
 // string representation are parsed from an expression of the form "path=repr".
+// An expression of the form "!path" resets the field or subtree (see Reset).
 // Panic if the field doesn't exist or parsing fails.
 //
 // See SetFromString for parsing rules.
 func MustAssign(config interface{}, expr string) {
+	if strings.HasPrefix(expr, "!") {
+		MustReset(config, strings.TrimSpace(expr[1:]))
+		return
+	}
+
 	tokens := strings.SplitN(expr, "=", 2)
 	if len(tokens) != 2 {
 		panic(fmt.Errorf("invalid assignment expression: %q", expr))
@@ -217,13 +230,25 @@ func Get(config interface{}, path string) (value interface{}, err error) {
 	return
 }
 
-func lookup(config interface{}, path string) (node reflect.Value) {
+func lookup(config interface{}, path string) reflect.Value {
+	return find(config, path, false)
+}
+
+// find a field.  Nil pointers to structs on the way are allocated if alloc
+// is true.
+func find(config interface{}, path string, alloc bool) (node reflect.Value) {
 	node = reflect.ValueOf(config)
 
 	for _, nodeName := range strings.Split(path, ".") {
-		if node.Kind() == reflect.Ptr {
+		if node.Kind() == reflect.Ptr && node.IsNil() && alloc && node.CanSet() && node.Type().Elem().Kind() == reflect.Struct {
+			node.Set(reflect.New(node.Type().Elem()))
+		}
+		if node.Kind() == reflect.Ptr && !node.IsNil() {
 			node = node.Elem()
 		}
+		if node.Kind() != reflect.Struct {
+			panic(fmt.Errorf("unknown config key: %q", path))
+		}
 
 		field, ok := node.Type().FieldByNameFunc(func(fieldName string) bool {
 			return strings.ToLower(fieldName) == nodeName
@@ -23,15 +23,56 @@ func (s Setting) String() string {
 	return s.Path
 }
 
-// Settings lists the settable configuration paths.
+// Settings lists the settable configuration paths.  If defaults have been
+// captured for the configuration object, they are reported instead of the
+// current values.
 func Settings(config interface{}) []Setting {
-	return enumerate(nil, "", reflect.ValueOf(config))
+	list := enumerate(nil, "", reflect.ValueOf(config))
+
+	if base := capturedDefaults(config); base.IsValid() {
+		values := make(map[string]string)
+		walk("", base, func(path string, _ reflect.StructField, value reflect.Value) {
+			values[path] = defaultString(value)
+		})
+
+		for i := range list {
+			list[i].Default = values[list[i].Path]
+		}
+	}
+
+	return list
 }
 
 func enumerate(list []Setting, prefix string, node reflect.Value) []Setting {
+	walk(prefix, node, func(path string, field reflect.StructField, value reflect.Value) {
+		list = append(list, Setting{
+			Path:    path,
+			Type:    value.Type(),
+			Default: defaultString(value),
+		})
+	})
+
+	return list
+}
+
+// defaultString representation of a value, or empty string for zero value.
+func defaultString(value reflect.Value) string {
+	if value.Kind() == reflect.Slice {
+		if repr := fmt.Sprintf("%q", value.Interface()); len(repr) > 2 {
+			return repr
+		}
+	} else if x := value.Interface(); x != reflect.Zero(value.Type()).Interface() {
+		return fmt.Sprint(x)
+	}
+
+	return ""
+}
+
+// walk calls visit for every settable field reachable from node.
+func walk(prefix string, node reflect.Value, visit func(path string, field reflect.StructField, value reflect.Value)) {
 	if node.Type().Kind() == reflect.Ptr {
 		if node.IsNil() {
-			return list
+			return
 		}
 		node = node.Elem()
 	}
@@ -55,39 +96,25 @@ func enumerate(list []Setting, prefix string, node reflect.Value) []Setting {
 		kind := field.Type.Kind()
 
 		if kind == reflect.Ptr {
-			list = enumerate(list, path, value)
+			if field.Type.Elem().Kind() == reflect.Struct {
+				walk(path, value, visit)
+			}
 		} else {
 			switch kind {
 			case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64, reflect.String:
-				s := Setting{
-					Path: path,
-					Type: value.Type(),
-				}
-				if x := value.Interface(); x != reflect.Zero(value.Type()).Interface() {
-					s.Default = fmt.Sprint(x)
-				}
-				list = append(list, s)
+				visit(path, field, value)
 
 			case reflect.Slice:
 				switch value.Type().Elem().Kind() {
 				case reflect.String:
-					s := Setting{
-						Path: path,
-						Type: value.Type(),
-					}
-					if repr := fmt.Sprintf("%q", value.Interface()); len(repr) > 2 {
-						s.Default = repr
-					}
-					list = append(list, s)
+					visit(path, field, value)
 				}
 
 			case reflect.Struct:
-				list = enumerate(list, path, value)
+				walk(path, value, visit)
 			}
 		}
 	}
-
-	return list
 }
 
 // PrintSettings of the given configuration.  Writer defaults to the default
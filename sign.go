@@ -0,0 +1,92 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// SignatureSuffix is appended to a filename to get the name of its detached
+// signature file.
+const SignatureSuffix = ".sig"
+
+// SignFile creates a detached ed25519 signature for a file.  The signature is
+// written in base64 encoding to a file with SignatureSuffix appended to the
+// filename.
+func SignFile(filename string, key ed25519.PrivateKey) (err error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(key, data)) + "\n"
+
+	unlock, err := lockFile(filename + SignatureSuffix)
+	if err != nil {
+		return
+	}
+	defer unlock()
+
+	return replaceFile(filename+SignatureSuffix, []byte(sig), 0644, true)
+}
+
+// ReadSignedFile reads a YAML file into the configuration after verifying its
+// detached signature (see SignFile).  The signature must have been made with
+// the private key of one of the trusted public keys.  Nothing is applied if
+// verification fails.  Signed files cannot include other files.
+func ReadSignedFile(filename string, config interface{}, trusted ...ed25519.PublicKey) (err error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return
+	}
+
+	if err = verify(filename, data, trusted); err != nil {
+		return
+	}
+
+	defer watch(config)()
+
+	return readDocuments(bytes.NewReader(data), func(doc *document) (err error) {
+		includes, err := doc.includes()
+		if err != nil {
+			return
+		}
+		if len(includes) > 0 {
+			return fmt.Errorf("%s: signed config file cannot include other files", filename)
+		}
+
+		return doc.decode(config)
+	})
+}
+
+func verify(filename string, data []byte, trusted []ed25519.PublicKey) (err error) {
+	encoded, err := ioutil.ReadFile(filename + SignatureSuffix)
+	if err != nil {
+		return
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(encoded)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("%s: malformed signature", filename+SignatureSuffix)
+	}
+
+	if len(trusted) == 0 {
+		return errors.New("no trusted public keys for config file verification")
+	}
+
+	for _, key := range trusted {
+		if len(key) == ed25519.PublicKeySize && ed25519.Verify(key, data, sig) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%s: signature verification failed", filename)
+}
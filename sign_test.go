@@ -0,0 +1,84 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"crypto/ed25519"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSignedFile(t *testing.T) {
+	public, private, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherPublic, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "config.yaml")
+
+	writeTestFiles(t, dir, map[string]string{
+		"config.yaml":   "bar: 1\n",
+		"include.yaml":  "include: [config.yaml]\n",
+		"unsigned.yaml": "bar: 2\n",
+	})
+
+	for _, name := range []string{"config.yaml", "include.yaml"} {
+		if err := SignFile(filepath.Join(dir, name), private); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c := new(testConfig)
+
+	s := flag.NewFlagSet("test", flag.ContinueOnError)
+	s.SetOutput(ioutil.Discard)
+	s.Var(SignedFileReader(c, otherPublic, public), "f", "read signed config files")
+
+	if err := s.Parse([]string{"-f", filename}); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bar != 1 {
+		t.Fail()
+	}
+
+	for _, name := range []string{"include.yaml", "unsigned.yaml"} {
+		if err := ReadSignedFile(filepath.Join(dir, name), c, public); err == nil {
+			t.Error(name)
+		}
+	}
+
+	if err := ReadSignedFile(filename, c, otherPublic); err == nil {
+		t.Fail()
+	}
+	if err := ReadSignedFile(filename, c); err == nil {
+		t.Fail()
+	}
+
+	if err := ioutil.WriteFile(filename, []byte("bar: 3\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ReadSignedFile(filename, c, public); err == nil {
+		t.Fail()
+	}
+	if c.Bar != 1 {
+		t.Error("tampered file was applied")
+	}
+
+	os.Remove(filename + SignatureSuffix)
+
+	if err := ReadSignedFile(filename, c, public); err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StandardFiles lists the locations of an application's configuration files
+// in the order in which ReadStandardFiles reads them:
+//
+//	/etc/<app>/config.yaml
+//	<dir>/<app>/config.yaml for each dir in $XDG_CONFIG_DIRS, last one first
+//	$XDG_CONFIG_HOME/<app>/config.yaml
+//	./<app>.yaml
+//
+// $XDG_CONFIG_DIRS defaults to /etc/xdg and $XDG_CONFIG_HOME defaults to
+// $HOME/.config.
+func StandardFiles(appName string) (filenames []string) {
+	const name = "config.yaml"
+
+	filenames = append(filenames, filepath.Join("/etc", appName, name))
+
+	dirs := os.Getenv("XDG_CONFIG_DIRS")
+	if dirs == "" {
+		dirs = "/etc/xdg"
+	}
+	list := filepath.SplitList(dirs)
+	for i := len(list) - 1; i >= 0; i-- {
+		if filepath.IsAbs(list[i]) {
+			filenames = append(filenames, filepath.Join(list[i], appName, name))
+		}
+	}
+
+	home := os.Getenv("XDG_CONFIG_HOME")
+	if home == "" {
+		if dir := os.Getenv("HOME"); dir != "" {
+			home = filepath.Join(dir, ".config")
+		}
+	}
+	if filepath.IsAbs(home) {
+		filenames = append(filenames, filepath.Join(home, appName, name))
+	}
+
+	filenames = append(filenames, appName+".yaml")
+	return
+}
+
+// ReadStandardFiles reads the application's configuration files which exist
+// in the standard locations (see StandardFiles).  Files read later override
+// values read from earlier files.  The names of the files which were found
+// are returned.
+func ReadStandardFiles(appName string, config interface{}) (found []string, err error) {
+	if appName == "" || strings.ContainsAny(appName, `/\`) {
+		err = fmt.Errorf("invalid application name: %q", appName)
+		return
+	}
+
+	for _, filename := range StandardFiles(appName) {
+		if err = ReadFile(filename, config); err != nil {
+			if os.IsNotExist(err) {
+				err = nil
+				continue
+			}
+			return
+		}
+
+		found = append(found, filename)
+	}
+
+	return
+}
+
+// ReadParentFiles reads ".<app>.yaml" files from a directory and its parent
+// directories, like .editorconfig files.  The search starts from the current
+// working directory if dir is empty.  It stops at the filesystem root, or at
+// the first directory which contains one of the root marker files (e.g.
+// ".git").  The outermost file is read first, so files in subdirectories
+// override values of their parent directories.  The names of the files which
+// were found are returned in the order in which they were read.
+func ReadParentFiles(dir, appName string, config interface{}, rootMarkers ...string) (found []string, err error) {
+	if appName == "" || strings.ContainsAny(appName, `/\`) {
+		err = fmt.Errorf("invalid application name: %q", appName)
+		return
+	}
+
+	if dir == "" {
+		dir = "."
+	}
+	if dir, err = filepath.Abs(dir); err != nil {
+		return
+	}
+
+	var filenames []string
+
+	for {
+		filename := filepath.Join(dir, "."+appName+".yaml")
+		if info, e := os.Stat(filename); e == nil && info.Mode().IsRegular() {
+			filenames = append(filenames, filename)
+		}
+
+		if isRootDir(dir, rootMarkers) {
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	for i := len(filenames) - 1; i >= 0; i-- {
+		if err = ReadFile(filenames[i], config); err != nil {
+			return
+		}
+		found = append(found, filenames[i])
+	}
+
+	return
+}
+
+func isRootDir(dir string, markers []string) bool {
+	for _, name := range markers {
+		if _, err := os.Lstat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
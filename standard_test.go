@@ -0,0 +1,98 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadStandardFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{
+		"xdg1/test-app/config.yaml": "bar: 1\n",
+		"xdg2/test-app/config.yaml": "bar: 2\nfoo:\n  key2: 2\n",
+		"home/test-app/config.yaml": "foo:\n  key1: true\n",
+		"work/test-app.yaml":        "foo:\n  key2: 4\n",
+	})
+
+	for name, value := range map[string]string{
+		"XDG_CONFIG_DIRS": filepath.Join(dir, "xdg1") + string(filepath.ListSeparator) + filepath.Join(dir, "xdg2"),
+		"XDG_CONFIG_HOME": filepath.Join(dir, "home"),
+	} {
+		t.Setenv(name, value)
+	}
+
+	t.Chdir(filepath.Join(dir, "work"))
+
+	c := new(testConfig)
+
+	found, err := ReadStandardFiles("test-app", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(found, []string{
+		filepath.Join(dir, "xdg2/test-app/config.yaml"),
+		filepath.Join(dir, "xdg1/test-app/config.yaml"),
+		filepath.Join(dir, "home/test-app/config.yaml"),
+		"test-app.yaml",
+	}) {
+		t.Error(found)
+	}
+
+	if c.Bar != 1 || !c.Foo.Key1 || c.Foo.Key2 != 4 {
+		t.Errorf("%#v", c.Foo)
+	}
+
+	if _, err := ReadStandardFiles("../test-app", c); err == nil {
+		t.Fail()
+	}
+}
+
+func TestReadParentFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{
+		".test-app.yaml":              "bar: 1\nfoo:\n  key10: outside\n",
+		"repo/.git/HEAD":              "",
+		"repo/.test-app.yaml":         "bar: 2\nfoo:\n  key2: 2\n",
+		"repo/a/b/.test-app.yaml":     "foo:\n  key1: true\n",
+		"repo/a/b/c/.test-app.yaml/x": "",
+	})
+
+	c := new(testConfig)
+
+	found, err := ReadParentFiles(filepath.Join(dir, "repo/a/b/c"), "test-app", c, ".git")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(found, []string{
+		filepath.Join(dir, "repo/.test-app.yaml"),
+		filepath.Join(dir, "repo/a/b/.test-app.yaml"),
+	}) {
+		t.Error(found)
+	}
+
+	if c.Bar != 2 || !c.Foo.Key1 || c.Foo.Key2 != 2 || c.Foo.Key10 != "" {
+		t.Errorf("%#v", c.Foo)
+	}
+
+	c = new(testConfig)
+
+	found, err = ReadParentFiles(filepath.Join(dir, "repo/a"), "test-app", c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) < 2 || found[len(found)-2] != filepath.Join(dir, ".test-app.yaml") {
+		t.Error(found)
+	}
+	if c.Bar != 2 || c.Foo.Key10 != "outside" {
+		t.Errorf("%#v", c.Foo)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"strings"
+	"sync"
+)
+
+type subscription struct {
+	pattern string
+	f       func(Change)
+}
+
+func (s *subscription) match(path string) bool {
+	switch {
+	case s.pattern == "*":
+		return true
+
+	case strings.HasSuffix(s.pattern, ".*"):
+		return strings.HasPrefix(path, s.pattern[:len(s.pattern)-1])
+
+	default:
+		return path == s.pattern
+	}
+}
+
+var (
+	subscriptionLock sync.Mutex
+	subscriptions    = make(map[interface{}][]*subscription)
+)
+
+// Subscribe to changes of a configuration object.  The pattern is a dotted
+// path, a path prefix such as "audio.*", or "*" which matches everything.
+// The function is called with the old and new values whenever Set, Assign,
+// Read or their variants change a matching field.
+//
+// The returned function cancels the subscription.
+func Subscribe(config interface{}, pattern string, f func(Change)) (cancel func()) {
+	s := &subscription{pattern, f}
+
+	subscriptionLock.Lock()
+	defer subscriptionLock.Unlock()
+
+	subscriptions[config] = append(subscriptions[config], s)
+
+	return func() {
+		subscriptionLock.Lock()
+		defer subscriptionLock.Unlock()
+
+		list := subscriptions[config]
+		for i, x := range list {
+			if x == s {
+				list = append(list[:i:i], list[i+1:]...)
+				break
+			}
+		}
+
+		if len(list) > 0 {
+			subscriptions[config] = list
+		} else {
+			delete(subscriptions, config)
+		}
+	}
+}
+
+// watch the configuration object for changes.  The returned function notifies
+// the subscribers about the changes made since the call.
+func watch(config interface{}) (done func()) {
+	subscriptionLock.Lock()
+	subs := subscriptions[config]
+	subscriptionLock.Unlock()
+
+	if len(subs) == 0 {
+		return func() {}
+	}
+
+	before := takeSnapshot(config)
+
+	return func() {
+		for _, c := range before.diff(takeSnapshot(config)) {
+			for _, s := range subs {
+				if s.match(c.Path) {
+					s.f(c)
+				}
+			}
+		}
+	}
+}
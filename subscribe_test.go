@@ -0,0 +1,61 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSubscribe(t *testing.T) {
+	c := new(testConfig)
+	c.Baz.Embed2.TestConfigEmbed = new(TestConfigEmbed)
+
+	var exact, prefix, all []Change
+
+	cancelExact := Subscribe(c, "foo.key2", func(x Change) { exact = append(exact, x) })
+	defer Subscribe(c, "baz.quux.*", func(x Change) { prefix = append(prefix, x) })()
+	defer Subscribe(c, "*", func(x Change) { all = append(all, x) })()
+
+	if err := Set(c, "foo.key2", 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := Assign(c, "foo.key2=5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Assign(c, "baz.quux.key_b=yes"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(exact, []Change{{"foo.key2", 0, 5}}) {
+		t.Errorf("%v", exact)
+	}
+	if !reflect.DeepEqual(prefix, []Change{{"baz.quux.key_b", false, true}}) {
+		t.Errorf("%v", prefix)
+	}
+	if len(all) != 2 {
+		t.Errorf("%v", all)
+	}
+
+	cancelExact()
+	exact = nil
+	all = nil
+
+	if err := Read(strings.NewReader(testConfigYAML), c); err != nil {
+		t.Fatal(err)
+	}
+
+	if exact != nil {
+		t.Errorf("%v", exact)
+	}
+	if len(all) != 18 {
+		t.Errorf("%d changes: %v", len(all), all)
+	}
+	if !reflect.DeepEqual(all[len(all)-1], Change{"baz.interval", time.Duration(0), c.Baz.Interval}) {
+		t.Errorf("%v", all[len(all)-1])
+	}
+}
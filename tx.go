@@ -0,0 +1,71 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io"
+	"reflect"
+)
+
+// Validator may be implemented by a configuration type.  The transactional
+// functions call Validate before committing changes.
+type Validator interface {
+	Validate() error
+}
+
+// ReadTx reads YAML into the configuration.  The configuration is not
+// modified unless the whole document is read and validated successfully.
+func ReadTx(r io.Reader, config interface{}) error {
+	return transact(config, func(tmp interface{}) error {
+		return Read(r, tmp)
+	})
+}
+
+// ReadFileTx reads a YAML file into the configuration.  The configuration is
+// not modified unless the whole file is read and validated successfully.
+func ReadFileTx(filename string, config interface{}) error {
+	return transact(config, func(tmp interface{}) error {
+		return ReadFile(filename, tmp)
+	})
+}
+
+// AssignAll applies assignment expressions to the configuration.  The
+// configuration is not modified unless all assignments and validation
+// succeed.
+//
+// See Assign for the expression syntax.
+func AssignAll(config interface{}, exprs ...string) error {
+	return transact(config, func(tmp interface{}) (err error) {
+		for _, expr := range exprs {
+			if err = Assign(tmp, expr); err != nil {
+				return
+			}
+		}
+		return
+	})
+}
+
+// transact applies changes to a copy of the configuration, validates it, and
+// commits it if there were no errors.
+func transact(config interface{}, apply func(tmp interface{}) error) (err error) {
+	tmp := duplicate(config)
+	defer shareKey(config, tmp.Interface())()
+	defer shareDefaults(config, tmp.Interface())()
+
+	if err = apply(tmp.Interface()); err != nil {
+		return
+	}
+
+	if v, ok := tmp.Interface().(Validator); ok {
+		if err = v.Validate(); err != nil {
+			return
+		}
+	}
+
+	defer watch(config)()
+
+	commit(reflect.ValueOf(config).Elem(), tmp.Elem())
+	return
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2018 Timo Savola. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type validatedConfig struct {
+	Foo struct {
+		Key1  bool
+		Key11 []string
+	}
+	Bar int
+}
+
+func (c *validatedConfig) Validate() error {
+	if c.Bar < 0 {
+		return errors.New("bar is negative")
+	}
+	return nil
+}
+
+func TestReadTx(t *testing.T) {
+	const bad = "foo:\n  key1: true\nbar: x\n"
+
+	c := new(testConfig)
+	c.Baz.Embed2.TestConfigEmbed = new(TestConfigEmbed)
+	embed2 := c.Baz.Embed2.TestConfigEmbed
+
+	if err := ReadTx(strings.NewReader(bad), c); err == nil {
+		t.Fail()
+	}
+	if c.Foo.Key1 {
+		t.Error("partially applied")
+	}
+
+	if err := ReadTx(strings.NewReader(testConfigYAML), c); err != nil {
+		t.Fatal(err)
+	}
+
+	testConfigValues(t, c)
+
+	if c.Baz.Embed2.TestConfigEmbed != embed2 {
+		t.Error("nested struct was replaced")
+	}
+
+	if err := Read(strings.NewReader(bad), c); err == nil {
+		t.Fail()
+	}
+}
+
+func TestAssignAll(t *testing.T) {
+	c := new(validatedConfig)
+	c.Bar = 1
+
+	if err := AssignAll(c, "foo.key1=true", "bar=x"); err == nil {
+		t.Fail()
+	}
+	if c.Foo.Key1 {
+		t.Error("partially applied")
+	}
+
+	if err := AssignAll(c, "foo.key1=true", "bar=-1"); err == nil {
+		t.Fail()
+	}
+	if c.Foo.Key1 || c.Bar != 1 {
+		t.Error("invalid configuration committed")
+	}
+
+	if err := AssignAll(c, "foo.key1=true", `foo.key11=["a"]`, "bar=2"); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Foo.Key1 || c.Bar != 2 || len(c.Foo.Key11) != 1 {
+		t.Fail()
+	}
+}
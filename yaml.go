@@ -5,29 +5,128 @@
 package config
 
 import (
+	"fmt"
 	"io"
+	"io/fs"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 
 	"gopkg.in/yaml.v2"
 )
 
-// Read YAML into the configuration.
+// Read YAML into the configuration.  If the stream contains multiple
+// documents, they are applied in order.
 func Read(r io.Reader, config interface{}) error {
-	return yaml.NewDecoder(r).Decode(config)
+	defer watch(config)()
+
+	return readDocuments(r, func(doc *document) error {
+		return doc.decode(config)
+	})
 }
 
-// Read a YAML file into the configuration.
-func ReadFile(filename string, config interface{}) (err error) {
-	f, err := os.Open(filename)
-	if err != nil {
+// ReadSelect reads YAML into the configuration, skipping documents which have
+// a different value for the top-level discriminator key.  Documents without
+// the key are applied.  E.g. ReadSelect(r, "profile", "prod", c) skips
+// documents which specify "profile: dev".
+func ReadSelect(r io.Reader, key, value string, config interface{}) error {
+	defer watch(config)()
+
+	return readDocuments(r, func(doc *document) (err error) {
+		var meta map[string]interface{}
+		if err = doc.unmarshal(&meta); err != nil {
+			return
+		}
+
+		if x, found := meta[key]; found && fmt.Sprint(x) != value {
+			return
+		}
+
+		return doc.decode(config)
+	})
+}
+
+// document captures a YAML document so that it can be decoded multiple times.
+type document struct {
+	unmarshal func(interface{}) error
+}
+
+func (doc *document) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	doc.unmarshal = unmarshal
+	return nil
+}
+
+// decode the document into the configuration and decrypt encrypted values
+// (see SetKey).
+func (doc *document) decode(config interface{}) error {
+	if err := doc.unmarshal(config); err != nil {
+		return err
+	}
+
+	return decryptValues(config)
+}
+
+// readDocuments calls f for each document in a YAML stream.  It's an error if
+// the stream contains no documents.
+func readDocuments(r io.Reader, f func(*document) error) error {
+	decoder := yaml.NewDecoder(r)
+
+	for i := 0; ; i++ {
+		var doc document
+
+		if err := decoder.Decode(&doc); err != nil {
+			if err == io.EOF && i > 0 {
+				err = nil
+			}
+			return err
+		}
+
+		if doc.unmarshal == nil {
+			continue // Empty document.
+		}
+
+		if err := f(&doc); err != nil {
+			return err
+		}
+	}
+}
+
+// Read a YAML file into the configuration.  The file may include other files
+// (see Include).
+func ReadFile(filename string, config interface{}) error {
+	return readFile(source{}, filename, config, nil)
+}
+
+// ReadFileSecure reads a YAML file into the configuration like ReadFile, but
+// refuses to read (and include) files which are writable by group or others,
+// or which are not owned by the effective user or root.  If the configuration
+// contains Secret fields, files which are readable by others are also
+// refused.  The directories leading to the file must also be owned by the
+// effective user or root, and not be writable by group or others unless they
+// have the sticky bit set (such as /tmp).  The checks are not made on
+// platforms which don't have Unix file permissions.
+func ReadFileSecure(filename string, config interface{}) error {
+	return readFile(source{secure: true}, filename, config, nil)
+}
+
+// ReadFS reads a YAML file from a file system into the configuration.  The file
+// may include other files in the same file system (see Include).
+func ReadFS(fsys fs.FS, name string, config interface{}) error {
+	return readFile(source{fsys: fsys}, name, config, nil)
+}
+
+// ReadDefaultsFS reads a YAML file from a file system (such as embed.FS) into
+// the configuration, and captures the resulting values as the defaults (see
+// Defaults).  It should be called before other sources are read.
+func ReadDefaultsFS(fsys fs.FS, name string, config interface{}) (err error) {
+	if err = ReadFS(fsys, name, config); err != nil {
 		return
 	}
-	defer f.Close()
 
-	return Read(f, config)
+	Defaults(config)
+	return
 }
 
 // Read a YAML file into the configuration.  No error is returned if the file
@@ -40,22 +139,250 @@ func ReadFileIfExists(filename string, config interface{}) (err error) {
 	return
 }
 
+// yamlExtensions are the filename extensions recognized by ReadDir.
+var yamlExtensions = []string{".yaml", ".yml"}
+
+// ReadDir reads the YAML files of a directory into the configuration in
+// lexical order.  Files with extensions other than .yaml or .yml, hidden
+// files and subdirectories are ignored.
+func ReadDir(dir string, config interface{}) error {
+	return readDir(source{}, dir, config)
+}
+
+func readDir(src source, dir string, config interface{}) (err error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || strings.HasPrefix(name, ".") || !isYAMLFilename(name) {
+			continue
+		}
+
+		if err = readFile(src, filepath.Join(dir, name), config, nil); err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func isYAMLFilename(name string) bool {
+	for _, ext := range yamlExtensions {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteOption customizes the output of Write and WriteFile.
+type WriteOption func(*writeOptions)
+
+type writeOptions struct {
+	annotated  bool
+	commentOut bool
+	minimal    bool
+	changed    func(path string, value reflect.Value) bool
+	perm       os.FileMode
+	permSet    bool
+	secrets    bool
+}
+
+func parseWriteOptions(options []WriteOption) (o writeOptions) {
+	for _, f := range options {
+		f(&o)
+	}
+	return
+}
+
+// Annotated output documents every setting with comments.  The description is
+// taken from the field's `doc` struct tag, and the allowed values from the
+// `values` struct tag (if any).  The type and the default value are also
+// included.
+func Annotated() WriteOption {
+	return func(o *writeOptions) {
+		o.annotated = true
+	}
+}
+
+// CommentedOut produces annotated output where also the keys and values are
+// commented out.  It's useful for generating example configuration files.
+func CommentedOut() WriteOption {
+	return func(o *writeOptions) {
+		o.annotated = true
+		o.commentOut = true
+	}
+}
+
+// Minimal output includes only the values which differ from the defaults
+// captured with Defaults, or from zero values if defaults haven't been
+// captured.  Unchanged settings are commented out in annotated output.
+func Minimal() WriteOption {
+	return func(o *writeOptions) {
+		o.minimal = true
+	}
+}
+
+// IncludeSecrets in the output.  By default Secret values are omitted, or
+// redacted in annotated output.
+func IncludeSecrets() WriteOption {
+	return func(o *writeOptions) {
+		o.secrets = true
+	}
+}
+
+// Perm sets the permissions of the file written by WriteFile.  By default an
+// existing file's permissions are preserved, and a new file is readable and
+// writable only by its owner.
+func Perm(mode os.FileMode) WriteOption {
+	return func(o *writeOptions) {
+		o.perm = mode
+		o.permSet = true
+	}
+}
+
 // Write the configuration as YAML.
-func Write(w io.Writer, config interface{}) error {
-	return yaml.NewEncoder(w).Encode(sanitize(nil, reflect.ValueOf(config).Elem()))
+func Write(w io.Writer, config interface{}, options ...WriteOption) (err error) {
+	o := parseWriteOptions(options)
+
+	data, err := encode(config, &o)
+	if err != nil {
+		return
+	}
+
+	_, err = w.Write(data)
+	return
+}
+
+func encode(config interface{}, o *writeOptions) ([]byte, error) {
+	if o.minimal {
+		o.changed = changedFilter(config)
+	}
+
+	if o.annotated {
+		return annotate(config, o)
+	}
+
+	return yaml.Marshal(sanitize(nil, reflect.ValueOf(config).Elem(), "", o.keep))
+}
+
+// keep checks if a settable value should be written.
+func (o *writeOptions) keep(path string, value reflect.Value) bool {
+	if isSecret(value) && !o.secrets {
+		return false
+	}
+	return o.changed == nil || o.changed(path, value)
+}
+
+// changedFilter returns a function which checks if a value differs from the
+// captured default value (or zero value).
+func changedFilter(config interface{}) func(string, reflect.Value) bool {
+	var base snapshot
+	if x := capturedDefaults(config); x.IsValid() {
+		base = takeSnapshot(x.Interface())
+	}
+
+	return func(path string, value reflect.Value) bool {
+		orig, found := base.values[path]
+		if !found {
+			orig = reflect.Zero(value.Type()).Interface()
+		}
+		return !equalValues(value.Interface(), orig)
+	}
+}
+
+// ReadAt reads YAML into a subtree of the configuration.  The path may refer
+// to a nested struct (such as "audio") or to a single field.  The whole
+// configuration is read if path is empty.
+func ReadAt(r io.Reader, path string, config interface{}) (err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			err = asError(x)
+		}
+	}()
+
+	defer watch(config)()
+
+	target := subtree(config, path).Addr().Interface()
+
+	return readDocuments(r, func(doc *document) error {
+		if err := doc.unmarshal(target); err != nil {
+			return err
+		}
+
+		return decryptValues(config)
+	})
 }
 
-// Write the configuration to a YAML file.
-func WriteFile(filename string, config interface{}) (err error) {
-	data, err := yaml.Marshal(sanitize(nil, reflect.ValueOf(config).Elem()))
+// WriteAt writes a subtree of the configuration as YAML.  The path may refer
+// to a nested struct (such as "audio") or to a single field.  The whole
+// configuration is written if path is empty.  Only the IncludeSecrets option
+// is applicable.
+func WriteAt(w io.Writer, path string, config interface{}, options ...WriteOption) (err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			err = asError(x)
+		}
+	}()
+
+	o := parseWriteOptions(options)
+
+	node := subtree(config, path)
+	if node.Kind() == reflect.Struct {
+		return yaml.NewEncoder(w).Encode(sanitize(nil, node, path, o.keep))
+	}
+
+	if isSecret(node) && !o.secrets {
+		return fmt.Errorf("config key is secret: %q", path)
+	}
+
+	return yaml.NewEncoder(w).Encode(node.Interface())
+}
+
+// subtree returns the addressable value of a field or a nested struct.
+func subtree(config interface{}, path string) (node reflect.Value) {
+	if path == "" {
+		node = reflect.ValueOf(config)
+	} else {
+		node = lookup(config, path)
+	}
+
+	if node.Kind() == reflect.Ptr {
+		if node.IsNil() {
+			panic(fmt.Errorf("config key is nil pointer: %q", path))
+		}
+		node = node.Elem()
+	}
+	return
+}
+
+// Write the configuration to a YAML file.  The file is replaced atomically,
+// and the ownership of an existing file is preserved.  If the filename is a
+// symbolic link, the link's target is replaced.  Concurrent writers are
+// serialized using an advisory lock on the file's directory.
+func WriteFile(filename string, config interface{}, options ...WriteOption) (err error) {
+	o := parseWriteOptions(options)
+
+	data, err := encode(config, &o)
+	if err != nil {
+		return
+	}
+
+	unlock, err := lockFile(filename)
 	if err != nil {
 		return
 	}
+	defer unlock()
 
-	return ioutil.WriteFile(filename, data, 0666)
+	return replaceFile(filename, data, o.perm, o.permSet)
 }
 
-func sanitize(sane yaml.MapSlice, struc reflect.Value) yaml.MapSlice {
+// sanitize converts a struct to an ordered YAML mapping.  If keep is not nil,
+// only the settable values for which it returns true are included.
+func sanitize(sane yaml.MapSlice, struc reflect.Value, prefix string, keep func(path string, value reflect.Value) bool) yaml.MapSlice {
 	for i := 0; i < struc.Type().NumField(); i++ {
 		value := struc.Field(i)
 		if !value.CanInterface() {
@@ -65,17 +392,29 @@ func sanitize(sane yaml.MapSlice, struc reflect.Value) yaml.MapSlice {
 		var (
 			field = struc.Type().Field(i)
 			kind  = field.Type.Kind()
+			path  = prefix
 			x     interface{}
 		)
 
+		if !field.Anonymous {
+			if len(path) > 0 {
+				path += "."
+			}
+			path += strings.ToLower(field.Name)
+		}
+
 		switch kind {
 		case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64, reflect.String:
-			x = value.Interface()
+			if keep == nil || keep(path, value) {
+				x = value.Interface()
+			}
 
 		case reflect.Slice:
 			switch value.Type().Elem().Kind() {
 			case reflect.String:
-				x = value.Interface()
+				if keep == nil || keep(path, value) {
+					x = value.Interface()
+				}
 			}
 
 		case reflect.Ptr:
@@ -90,8 +429,8 @@ func sanitize(sane yaml.MapSlice, struc reflect.Value) yaml.MapSlice {
 
 		case reflect.Struct:
 			if field.Anonymous {
-				sane = sanitize(sane, value)
-			} else if s := sanitize(nil, value); len(s) > 0 {
+				sane = sanitize(sane, value, path, keep)
+			} else if s := sanitize(nil, value, path, keep); len(s) > 0 {
 				x = s
 			}
 		}
@@ -6,8 +6,10 @@ package config
 
 import (
 	"bytes"
+	"path/filepath"
 	"strings"
 	"testing"
+	"testing/fstest"
 )
 
 func TestRead(t *testing.T) {
@@ -50,3 +52,179 @@ func TestWrite(t *testing.T) {
 		t.Error(s)
 	}
 }
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+
+	writeTestFiles(t, dir, map[string]string{
+		"10-base.yaml":     "bar: 1\nfoo:\n  key1: true\n",
+		"20-override.yml":  "bar: 2\n",
+		"30-ignored.txt":   "bar: 3\n",
+		".40-hidden.yaml":  "bar: 4\n",
+		"50-sub/conf.yaml": "bar: 5\n",
+		"05-early.yaml":    "bar: 6\nfoo:\n  key2: 6\n",
+		"60-included.yml~": "bar: 7\n",
+	})
+
+	c := new(testConfig)
+
+	if err := ReadDir(dir, c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bar != 2 || !c.Foo.Key1 || c.Foo.Key2 != 6 {
+		t.Errorf("%#v", c)
+	}
+
+	if ReadDir(filepath.Join(dir, "nonexistent"), c) == nil {
+		t.Fail()
+	}
+}
+
+func TestReadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults/config.yaml": {Data: []byte("include: [common.yaml]\nbar: 1\n")},
+		"defaults/common.yaml": {Data: []byte("foo:\n  key1: true\n  key10: default\n")},
+		"outside.yaml":         {Data: []byte("include: [../nonexistent.yaml]\n")},
+	}
+
+	c := new(testConfig)
+
+	if err := ReadDefaultsFS(fsys, "defaults/config.yaml", c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bar != 1 || !c.Foo.Key1 || c.Foo.Key10 != "default" {
+		t.Errorf("%#v", c.Foo)
+	}
+
+	if err := Read(strings.NewReader("foo:\n  key10: override\n"), c); err != nil {
+		t.Fatal(err)
+	}
+
+	b := new(bytes.Buffer)
+	PrintSettings(b, c)
+	if s := b.String(); !strings.Contains(s, "  foo.key10 string (default)\n") {
+		t.Error(s)
+	}
+
+	if ReadFS(fsys, "outside.yaml", c) == nil {
+		t.Fail()
+	}
+}
+
+func TestReadMultipleDocuments(t *testing.T) {
+	const stream = `bar: 1
+foo:
+  key1: true
+---
+---
+profile: dev
+bar: 2
+---
+profile: prod
+bar: 3
+---
+foo:
+  key2: 4
+`
+
+	c := new(testConfig)
+
+	if err := Read(strings.NewReader(stream), c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Bar != 3 || !c.Foo.Key1 || c.Foo.Key2 != 4 {
+		t.Errorf("%#v", c)
+	}
+
+	for profile, bar := range map[string]int{"dev": 2, "prod": 3, "test": 1} {
+		c := new(testConfig)
+
+		if err := ReadSelect(strings.NewReader(stream), "profile", profile, c); err != nil {
+			t.Fatal(err)
+		}
+		if c.Bar != bar || !c.Foo.Key1 || c.Foo.Key2 != 4 {
+			t.Errorf("%s: %#v", profile, c)
+		}
+	}
+
+	if Read(strings.NewReader(""), c) == nil {
+		t.Error("empty stream")
+	}
+	if Read(strings.NewReader("bar: 1\n---\nbar: x\n"), c) == nil {
+		t.Error("invalid second document")
+	}
+}
+
+func TestReadWriteAt(t *testing.T) {
+	c := new(testConfig)
+	c.Baz.Embed2.TestConfigEmbed = new(TestConfigEmbed)
+
+	if err := ReadAt(strings.NewReader("key_a: hello\nkey_b: true\n"), "baz.quux", c); err != nil {
+		t.Fatal(err)
+	}
+	if err := ReadAt(strings.NewReader("123\n"), "bar", c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Baz.Quux.Key_a != "hello" || !c.Baz.Quux.Key_b || c.Bar != 123 {
+		t.Errorf("%#v", c)
+	}
+
+	b := new(bytes.Buffer)
+	if err := WriteAt(b, "baz.quux", c); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); s != "key_a: hello\nkey_b: true\n" {
+		t.Error(s)
+	}
+
+	b.Reset()
+	if err := WriteAt(b, "bar", c); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); s != "123\n" {
+		t.Error(s)
+	}
+
+	if ReadAt(strings.NewReader("x: 1\n"), "nonexistent", c) == nil {
+		t.Fail()
+	}
+	if WriteAt(b, "baz.embed2.nonexistent", c) == nil {
+		t.Fail()
+	}
+}
+
+func TestWriteMinimal(t *testing.T) {
+	c := new(testConfig)
+	c.Bar = 100
+	c.Foo.Key11 = []string{"default"}
+
+	b := new(bytes.Buffer)
+	if err := Write(b, c, Minimal()); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); s != "foo:\n  key11:\n  - default\nbar: 100\n" {
+		t.Error(s)
+	}
+
+	Defaults(c)
+
+	c.Bar = 0
+	c.Foo.Key1 = true
+	c.Baz.Quux.Key_a = "x"
+
+	b.Reset()
+	if err := Write(b, c, Minimal()); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); s != "foo:\n  key1: true\nbar: 0\nbaz:\n  quux:\n    key_a: x\n" {
+		t.Error(s)
+	}
+
+	b.Reset()
+	if err := Write(b, c, Minimal(), Annotated()); err != nil {
+		t.Fatal(err)
+	}
+	if s := b.String(); !strings.Contains(s, "\n  key1: true\n") || !strings.Contains(s, "\n  # key2: 0\n") {
+		t.Error(s)
+	}
+}